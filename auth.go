@@ -0,0 +1,262 @@
+package htest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// Challenge is one challenge of WWW-Authenticate, such as `Digest realm="api", qop="auth"`
+	Challenge struct {
+		Scheme string
+		Params map[string]string
+	}
+
+	Authenticate struct {
+		challenges []Challenge
+		exchangeT
+	}
+
+	// digestAuth answers Digest challenges (RFC 7616) with the credentials, it is shared by copies of Client
+	digestAuth struct {
+		user   string
+		pass   string
+		mu     sync.Mutex
+		counts map[string]int
+	}
+)
+
+func (r *Request) BasicAuth(user, pass string) *Request {
+	r.Header.Set(HeaderAuthorization, "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+pass)))
+	return r
+}
+
+func (r *Request) Bearer(token string) *Request {
+	r.Header.Set(HeaderAuthorization, "Bearer "+token)
+	return r
+}
+
+// APIKey sets the key in header name, such as X-API-Key
+func (r *Request) APIKey(name, key string) *Request {
+	r.Header.Set(name, key)
+	return r
+}
+
+// APIKeyQuery sets the key in query parameter name, such as api_key
+func (r *Request) APIKeyQuery(name, key string) *Request {
+	query := r.URL.Query()
+	query.Set(name, key)
+	r.URL.RawQuery = query.Encode()
+	return r
+}
+
+// DigestAuth returns a client which answers a 401 Digest challenge with user and pass and retries the request once
+func (c Client) DigestAuth(user, pass string) *Client {
+	c.digest = &digestAuth{
+		user:   user,
+		pass:   pass,
+		counts: make(map[string]int),
+	}
+	return &c
+}
+
+// replayable keeps the body so that the request can be retried with credentials or archived
+func (r *Request) replayable() {
+	if r.GetBody == nil && r.client != nil && (r.client.digest != nil || r.client.har != nil) {
+		r.bodyBytes()
+	}
+}
+
+// digestRetry returns the request with the answer to the Digest challenge of response, nil if there is none to answer
+func (r *Request) digestRetry(response *Response) *Request {
+	if r.client == nil || r.client.digest == nil || response.StatusCode != http.StatusUnauthorized || r.Header.Get(HeaderAuthorization) != "" {
+		return nil
+	}
+	for _, challenge := range parseChallenges(response.Header[http.CanonicalHeaderKey(HeaderWWWAuthenticate)]) {
+		if !strings.EqualFold(challenge.Scheme, "Digest") {
+			continue
+		}
+		retry := r.clone(r.URL)
+		authorization, ok := r.client.digest.authorize(retry, challenge)
+		if !ok {
+			continue
+		}
+		response.Body.Close()
+		retry.Header.Set(HeaderAuthorization, authorization)
+		return retry
+	}
+	return nil
+}
+
+func digestHash(algorithm string) func(s string) string {
+	var name string
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "", "MD5":
+		name = DigestMD5
+	case "SHA-256":
+		name = DigestSHA256
+	default:
+		return nil
+	}
+	return func(s string) string {
+		h := newHash(name)
+		h.Write([]byte(s))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+func (d *digestAuth) nonceCount(nonce string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.counts[nonce]++
+	return fmt.Sprintf("%08x", d.counts[nonce])
+}
+
+// authorize computes the Authorization header of r for challenge, qop auth is preferred to auth-int
+func (d *digestAuth) authorize(r *Request, challenge Challenge) (string, bool) {
+	algorithm := challenge.Params["algorithm"]
+	h := digestHash(algorithm)
+	if h == nil {
+		return "", false
+	}
+	var qop string
+	if value, exist := challenge.Params["qop"]; exist {
+		for _, option := range strings.Split(value, ",") {
+			option = strings.TrimSpace(option)
+			if option == "auth" || (option == "auth-int" && qop == "") {
+				qop = option
+			}
+		}
+		if qop == "" {
+			return "", false
+		}
+	}
+	realm, nonce, uri := challenge.Params["realm"], challenge.Params["nonce"], r.URL.RequestURI()
+	cnonce := make([]byte, 16)
+	_, err := rand.Read(cnonce)
+	assert.Nil(r.T, err)
+	nc, clientNonce := d.nonceCount(nonce), hex.EncodeToString(cnonce)
+
+	ha1 := h(d.user + ":" + realm + ":" + d.pass)
+	if strings.HasSuffix(strings.ToLower(algorithm), "-sess") {
+		ha1 = h(ha1 + ":" + nonce + ":" + clientNonce)
+	}
+	ha2 := h(r.Method + ":" + uri)
+	if qop == "auth-int" {
+		var body []byte
+		if r.GetBody != nil {
+			reader, err := r.GetBody()
+			assert.Nil(r.T, err)
+			body, err = ioutil.ReadAll(reader)
+			assert.Nil(r.T, err)
+		}
+		ha2 = h(r.Method + ":" + uri + ":" + h(string(body)))
+	}
+	var response string
+	if qop == "" {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + nc + ":" + clientNonce + ":" + qop + ":" + ha2)
+	}
+
+	params := []string{
+		fmt.Sprintf("username=%q", d.user),
+		fmt.Sprintf("realm=%q", realm),
+		fmt.Sprintf("nonce=%q", nonce),
+		fmt.Sprintf("uri=%q", uri),
+	}
+	if algorithm != "" {
+		params = append(params, "algorithm="+algorithm)
+	}
+	if qop != "" {
+		params = append(params, "qop="+qop, "nc="+nc, fmt.Sprintf("cnonce=%q", clientNonce))
+	}
+	params = append(params, fmt.Sprintf("response=%q", response))
+	if opaque, exist := challenge.Params["opaque"]; exist {
+		params = append(params, fmt.Sprintf("opaque=%q", opaque))
+	}
+	return "Digest " + strings.Join(params, ", "), true
+}
+
+// parseChallenges parses WWW-Authenticate values, a value may carry several challenges separated by commas
+func parseChallenges(values []string) []Challenge {
+	var challenges []Challenge
+	for _, value := range values {
+		for _, item := range splitQuoted(value, ',') {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			param := item
+			if space := strings.IndexByte(item, ' '); space >= 0 && !strings.Contains(item[:space], "=") {
+				challenges = append(challenges, Challenge{Scheme: item[:space], Params: make(map[string]string)})
+				param = strings.TrimSpace(item[space+1:])
+			} else if !strings.Contains(item, "=") {
+				challenges = append(challenges, Challenge{Scheme: item, Params: make(map[string]string)})
+				continue
+			}
+			if len(challenges) == 0 {
+				continue
+			}
+			if eq := strings.IndexByte(param, '='); eq >= 0 {
+				key := strings.ToLower(strings.TrimSpace(param[:eq]))
+				challenges[len(challenges)-1].Params[key] = strings.Trim(strings.TrimSpace(param[eq+1:]), `"`)
+			}
+		}
+	}
+	return challenges
+}
+
+func (r *Response) WWWAuthenticate() *Authenticate {
+	return &Authenticate{
+		challenges: parseChallenges(r.Header[http.CanonicalHeaderKey(HeaderWWWAuthenticate)]),
+		exchangeT:  r.t(),
+	}
+}
+
+func (a *Authenticate) challenge(scheme string) (Challenge, bool) {
+	for _, challenge := range a.challenges {
+		if strings.EqualFold(challenge.Scheme, scheme) {
+			return challenge, true
+		}
+	}
+	return Challenge{}, false
+}
+
+// Scheme asserts there is a challenge of scheme, case insensitive
+func (a *Authenticate) Scheme(scheme string) *Authenticate {
+	_, exist := a.challenge(scheme)
+	assert.True(a.exchangeT, exist, "no %s challenge in %v", scheme, a.challenges)
+	return a
+}
+
+func (a *Authenticate) NotScheme(scheme string) *Authenticate {
+	_, exist := a.challenge(scheme)
+	assert.False(a.exchangeT, exist, "unexpected %s challenge", scheme)
+	return a
+}
+
+// Param asserts the parameter key of the first challenge of scheme
+func (a *Authenticate) Param(scheme, key, expect string) *Authenticate {
+	challenge, exist := a.challenge(scheme)
+	if !assert.True(a.exchangeT, exist, "no %s challenge in %v", scheme, a.challenges) {
+		return a
+	}
+	value, exist := challenge.Params[strings.ToLower(key)]
+	if assert.True(a.exchangeT, exist, "no %s in %s challenge", key, scheme) {
+		assert.Equal(a.exchangeT, expect, value)
+	}
+	return a
+}
+
+func (a *Authenticate) Challenges() []Challenge {
+	return a.challenges
+}
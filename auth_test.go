@@ -0,0 +1,175 @@
+package htest
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	AuthUser   = "hexi"
+	AuthPass   = "secret"
+	AuthToken  = "token-hexi"
+	AuthAPIKey = "key-hexi"
+	AuthRealm  = "api@htest"
+	AuthNonce  = "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+	AuthOpaque = "5ccc069c403ebaf9f0171e9517f40e41"
+)
+
+func TestRequest_BasicAuth(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.Get("/auth/basic").BasicAuth(AuthUser, AuthPass).Test().
+		StatusOK().
+		JSON().
+		String("name", AuthUser)
+	client.Get("/auth/basic").BasicAuth(AuthUser, "wrong").Test().
+		StatusUnauthorized().
+		WWWAuthenticate().
+		Scheme("Basic").
+		Param("basic", "realm", AuthRealm)
+}
+
+func TestRequest_Bearer(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.Get("/auth/bearer").Bearer(AuthToken).Test().StatusOK()
+	client.Get("/auth/bearer").Test().
+		StatusUnauthorized().
+		WWWAuthenticate().
+		Param("Bearer", "error", "invalid_token").
+		Param("Bearer", "error_description", "token is missing, expired, or revoked")
+}
+
+func TestRequest_APIKey(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.Get("/auth/apikey").APIKey("X-API-Key", AuthAPIKey).Test().StatusOK()
+	client.Get("/auth/apikey?page=1").APIKeyQuery("api_key", AuthAPIKey).Test().StatusOK()
+	client.Get("/auth/apikey").APIKey("X-API-Key", "wrong").Test().StatusUnauthorized()
+}
+
+func TestClient_DigestAuth(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.Get("/auth/digest").Test().
+		StatusUnauthorized().
+		WWWAuthenticate().
+		Scheme("Digest").
+		NotScheme("Basic").
+		Param("Digest", "realm", AuthRealm).
+		Param("Digest", "qop", "auth, auth-int").
+		Param("Digest", "algorithm", "SHA-256").
+		Param("Digest", "opaque", AuthOpaque)
+
+	digest := client.DigestAuth(AuthUser, AuthPass)
+	digest.Get("/auth/digest?page=1").Test().
+		StatusOK().
+		JSON().
+		String("name", AuthUser).
+		String("qop", "auth").
+		String("nc", "00000001")
+	digest.Get("/auth/digest?page=2").Test().
+		StatusOK().
+		JSON().
+		String("nc", "00000002")
+	digest.Get("/auth/digest?algorithm=MD5-sess").Test().
+		StatusOK().
+		JSON().
+		String("algorithm", "MD5-sess")
+	digest.Post("/auth/digest?qop=auth-int", strings.NewReader(UserData)).Test().
+		StatusOK().
+		JSON().
+		String("qop", "auth-int")
+
+	client.DigestAuth(AuthUser, "wrong").Get("/auth/digest").Test().StatusUnauthorized()
+}
+
+func TestParseChallenges(t *testing.T) {
+	challenges := parseChallenges([]string{
+		`Newauth realm="apps", type=1, title="Login to \"apps\"", Basic realm="simple"`,
+		`Negotiate`,
+	})
+	assert.Equal(t, []string{"Newauth", "Basic", "Negotiate"}, []string{challenges[0].Scheme, challenges[1].Scheme, challenges[2].Scheme})
+	assert.Equal(t, "apps", challenges[0].Params["realm"])
+	assert.Equal(t, "1", challenges[0].Params["type"])
+	assert.Equal(t, "simple", challenges[1].Params["realm"])
+	assert.Empty(t, challenges[2].Params)
+}
+
+func unauthorized(w http.ResponseWriter, challenges ...string) {
+	for _, challenge := range challenges {
+		w.Header().Add(HeaderWWWAuthenticate, challenge)
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+func BasicAuthHandler(w http.ResponseWriter, req *http.Request) {
+	user, pass, ok := req.BasicAuth()
+	if !ok || user != AuthUser || pass != AuthPass {
+		unauthorized(w, fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, AuthRealm))
+		return
+	}
+	fmt.Fprintf(w, `{"name": %q}`, user)
+}
+
+func BearerAuthHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get(HeaderAuthorization) != "Bearer "+AuthToken {
+		unauthorized(w, `Bearer realm="example", error="invalid_token", error_description="token is missing, expired, or revoked"`)
+		return
+	}
+	io.WriteString(w, UserData)
+}
+
+func APIKeyHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("X-API-Key") != AuthAPIKey && req.URL.Query().Get("api_key") != AuthAPIKey {
+		unauthorized(w)
+		return
+	}
+	io.WriteString(w, UserData)
+}
+
+// DigestAuthHandler verifies RFC 7616 credentials, algorithm and qop of the challenge can be chosen by query
+func DigestAuthHandler(w http.ResponseWriter, req *http.Request) {
+	algorithm, qop := req.URL.Query().Get("algorithm"), req.URL.Query().Get("qop")
+	if algorithm == "" {
+		algorithm = "SHA-256"
+	}
+	if qop == "" {
+		qop = "auth, auth-int"
+	}
+	challenge := fmt.Sprintf(`Digest realm=%q, qop=%q, algorithm=%s, nonce=%q, opaque=%q`, AuthRealm, qop, algorithm, AuthNonce, AuthOpaque)
+	credentials := parseChallenges([]string{req.Header.Get(HeaderAuthorization)})
+	if len(credentials) == 0 || credentials[0].Scheme != "Digest" {
+		unauthorized(w, challenge)
+		return
+	}
+	params := credentials[0].Params
+	hashFunc := md5.New
+	if strings.HasPrefix(algorithm, "SHA-256") {
+		hashFunc = sha256.New
+	}
+	h := func(s string) string {
+		sum := hashFunc()
+		io.WriteString(sum, s)
+		return fmt.Sprintf("%x", sum.Sum(nil))
+	}
+	ha1 := h(params["username"] + ":" + AuthRealm + ":" + AuthPass)
+	if strings.HasSuffix(algorithm, "-sess") {
+		ha1 = h(ha1 + ":" + params["nonce"] + ":" + params["cnonce"])
+	}
+	ha2 := h(req.Method + ":" + params["uri"])
+	if params["qop"] == "auth-int" {
+		body, _ := ioutil.ReadAll(req.Body)
+		ha2 = h(req.Method + ":" + params["uri"] + ":" + h(string(body)))
+	}
+	expect := h(ha1 + ":" + params["nonce"] + ":" + params["nc"] + ":" + params["cnonce"] + ":" + params["qop"] + ":" + ha2)
+	if params["response"] != expect || params["opaque"] != AuthOpaque || params["uri"] != req.URL.RequestURI() || params["algorithm"] != algorithm {
+		unauthorized(w, challenge)
+		return
+	}
+	fmt.Fprintf(w, `{"name": %q, "qop": %q, "nc": %q, "algorithm": %q}`, params["username"], params["qop"], params["nc"], params["algorithm"])
+}
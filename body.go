@@ -2,22 +2,26 @@ package htest
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
 	"time"
 
 	xml2json "github.com/basgys/goxml2json"
 	"github.com/stretchr/testify/assert"
 	"github.com/tidwall/gjson"
+	"github.com/xeipuuv/gojsonschema"
 )
 
 type (
 	JSON struct {
 		body []byte
-		*testing.T
+		exchangeT
 	}
 
 	XML struct {
@@ -27,42 +31,58 @@ type (
 
 	MD5 struct {
 		body []byte
-		*testing.T
+		exchangeT
 	}
 
 	SHA1 struct {
 		body []byte
-		*testing.T
+		exchangeT
 	}
 )
 
 func NewJSON(body []byte, t *testing.T) *JSON {
+	return newJSON(body, exchangeT{T: t})
+}
+
+func newJSON(body []byte, t exchangeT) *JSON {
 	return &JSON{
-		body: body,
-		T:    t,
+		body:      body,
+		exchangeT: t,
 	}
 }
 
 func NewXML(body []byte, t *testing.T) *XML {
+	return newXML(body, exchangeT{T: t})
+}
+
+func newXML(body []byte, t exchangeT) *XML {
 	jsonBuf, _ := xml2json.Convert(bytes.NewBuffer(body))
 	jsonBody, _ := ioutil.ReadAll(jsonBuf)
 	return &XML{
 		body: body,
-		JSON: NewJSON(jsonBody, t),
+		JSON: newJSON(jsonBody, t),
 	}
 }
 
 func NewMD5(body []byte, t *testing.T) *MD5 {
+	return newMD5(body, exchangeT{T: t})
+}
+
+func newMD5(body []byte, t exchangeT) *MD5 {
 	return &MD5{
-		body: body,
-		T:    t,
+		body:      body,
+		exchangeT: t,
 	}
 }
 
 func NewSHA1(body []byte, t *testing.T) *SHA1 {
+	return newSHA1(body, exchangeT{T: t})
+}
+
+func newSHA1(body []byte, t exchangeT) *SHA1 {
 	return &SHA1{
-		body: body,
-		T:    t,
+		body:      body,
+		exchangeT: t,
 	}
 }
 
@@ -74,70 +94,89 @@ func (j *JSON) GetKey(key string) (result gjson.Result, exist bool) {
 
 func (j *JSON) Exist(key string) *JSON {
 	_, exist := j.GetKey(key)
-	assert.True(j.T, exist)
+	assert.True(j.exchangeT, exist)
 	return j
 }
 
 func (j *JSON) NotExist(key string) *JSON {
 	_, exist := j.GetKey(key)
-	assert.False(j.T, exist)
+	assert.False(j.exchangeT, exist)
 	return j
 }
 
 func (j *JSON) String(key, expect string) *JSON {
 	result, _ := j.GetKey(key)
-	assert.Equal(j.T, expect, result.String())
+	assert.Equal(j.exchangeT, expect, result.String())
 	return j
 }
 
 func (j *JSON) Int(key string, expect int64) *JSON {
 	result, _ := j.GetKey(key)
-	assert.Equal(j.T, expect, result.Int())
+	assert.Equal(j.exchangeT, expect, result.Int())
 	return j
 }
 
 func (j *JSON) True(key string) *JSON {
 	result, _ := j.GetKey(key)
-	assert.True(j.T, result.Bool())
+	assert.True(j.exchangeT, result.Bool())
 	return j
 }
 
 func (j *JSON) False(key string) *JSON {
 	result, _ := j.GetKey(key)
-	assert.False(j.T, result.Bool())
+	assert.False(j.exchangeT, result.Bool())
 	return j
 }
 
 func (j *JSON) Uint(key string, expect uint64) *JSON {
 	result, _ := j.GetKey(key)
-	assert.Equal(j.T, expect, result.Uint())
+	assert.Equal(j.exchangeT, expect, result.Uint())
 	return j
 }
 
 func (j *JSON) Time(key string, expect time.Time) *JSON {
 	result, _ := j.GetKey(key)
-	assert.Equal(j.T, expect, result.Time())
+	assert.Equal(j.exchangeT, expect, result.Time())
 	return j
 }
 
 func (j *JSON) Float(key string, expect float64) *JSON {
 	result, _ := j.GetKey(key)
-	assert.Equal(j.T, expect, result.Float())
+	assert.Equal(j.exchangeT, expect, result.Float())
 	return j
 }
 
 func (j *JSON) Empty() *JSON {
 	body := bytes.Trim(j.Body(), "\"\n")
-	assert.Equal(j.T, "", string(body))
+	assert.Equal(j.exchangeT, "", string(body))
 	return j
 }
 
 func (j *JSON) NotEmpty() *JSON {
 	body := bytes.Trim(j.Body(), "\"\n")
-	assert.NotEqual(j.T, "", string(body))
+	assert.NotEqual(j.exchangeT, "", string(body))
+	return j
+}
+
+// Schema validates the body against a JSON Schema document
+func (j *JSON) Schema(schema string) *JSON {
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if assert.Nil(j.exchangeT, err) {
+		validateSchema(j.exchangeT, compiled, j.body, "")
+	}
 	return j
 }
 
+func validateSchema(t assert.TestingT, schema *gojsonschema.Schema, body []byte, prefix string) {
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(body))
+	if !assert.Nil(t, err, "%sinvalid json", prefix) {
+		return
+	}
+	for _, violation := range result.Errors() {
+		assert.Fail(t, "schema violation", "%s%s", prefix, violation)
+	}
+}
+
 func (j *JSON) Body() []byte {
 	return j.body
 }
@@ -194,13 +233,18 @@ func (x *XML) Float(key string, expect float64) *XML {
 	return x
 }
 
+func (x *XML) Schema(schema string) *XML {
+	x.JSON.Schema(schema)
+	return x
+}
+
 func (x *XML) Empty() *XML {
-	assert.Equal(x.T, "", string(x.Body()))
+	assert.Equal(x.exchangeT, "", string(x.Body()))
 	return x
 }
 
 func (x *XML) NotEmpty() *XML {
-	assert.NotEqual(x.T, "", string(x.Body()))
+	assert.NotEqual(x.exchangeT, "", string(x.Body()))
 	return x
 }
 
@@ -213,7 +257,17 @@ func (x *XML) Bind(obj interface{}) error {
 }
 
 func (m *MD5) Expect(expect string) *MD5 {
-	assert.Equal(m.T, expect, string(m.Body()))
+	assert.Equal(m.exchangeT, expect, string(m.Body()))
+	return m
+}
+
+func (m *MD5) ExpectHex(expect string) *MD5 {
+	assert.Equal(m.exchangeT, strings.ToLower(expect), hex.EncodeToString(m.Body()))
+	return m
+}
+
+func (m *MD5) ExpectBase64(expect string) *MD5 {
+	assert.Equal(m.exchangeT, expect, base64.StdEncoding.EncodeToString(m.Body()))
 	return m
 }
 
@@ -222,7 +276,17 @@ func (m *MD5) Body() []byte {
 }
 
 func (s *SHA1) Expect(expect string) *SHA1 {
-	assert.Equal(s.T, expect, string(s.Body()))
+	assert.Equal(s.exchangeT, expect, string(s.Body()))
+	return s
+}
+
+func (s *SHA1) ExpectHex(expect string) *SHA1 {
+	assert.Equal(s.exchangeT, strings.ToLower(expect), hex.EncodeToString(s.Body()))
+	return s
+}
+
+func (s *SHA1) ExpectBase64(expect string) *SHA1 {
+	assert.Equal(s.exchangeT, expect, base64.StdEncoding.EncodeToString(s.Body()))
 	return s
 }
 
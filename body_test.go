@@ -95,6 +95,11 @@ func TestJSON_Float(t *testing.T) {
 		Float("number", float64(1))
 }
 
+func TestJSON_Schema(t *testing.T) {
+	NewJSON([]byte(UserData), t).
+		Schema(UserSchema)
+}
+
 func TestJSON_Bind(t *testing.T) {
 	user := new(User)
 	NewClient(t).
@@ -0,0 +1,332 @@
+package htest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// Cassette modes
+const (
+	// CassetteReplay serves the recording without network, an unmatched request fails the test
+	CassetteReplay CassetteMode = iota
+	// CassetteRecord sends requests to the real server and records them, Eject writes the file
+	CassetteRecord
+	// CassetteAuto replays if the file exists, otherwise it records
+	CassetteAuto
+)
+
+const (
+	// CassetteDir is where recordings are, unless Cassette.Dir says otherwise
+	CassetteDir      = "testdata"
+	CassetteRedacted = "REDACTED"
+)
+
+type (
+	CassetteMode int
+
+	// Cassette records Request.Send exchanges to <name>.yaml (or .json) in CassetteDir or Dir, and replays them.
+	// A request matches an interaction by method, url and body after redaction, each interaction is played once.
+	Cassette struct {
+		Interactions []*Interaction
+
+		dir     string
+		name    string
+		mode    CassetteMode
+		once    sync.Once
+		headers []string
+		query   []string
+		bodies  []bodyRedaction
+		played  []bool
+		mu      sync.Mutex
+		*testing.T
+	}
+
+	cassetteFile struct {
+		Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+	}
+
+	Interaction struct {
+		Request  CassetteRequest  `json:"request" yaml:"request"`
+		Response CassetteResponse `json:"response" yaml:"response"`
+	}
+
+	CassetteRequest struct {
+		Method   string      `json:"method" yaml:"method"`
+		URL      string      `json:"url" yaml:"url"`
+		Header   http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+		Body     string      `json:"body,omitempty" yaml:"body,omitempty"`
+		Encoding string      `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	}
+
+	CassetteResponse struct {
+		Code     int         `json:"code" yaml:"code"`
+		Header   http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+		Body     string      `json:"body,omitempty" yaml:"body,omitempty"`
+		Encoding string      `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	}
+
+	bodyRedaction struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}
+)
+
+// NewCassette opens <name> in CassetteDir, the format is JSON if name ends with .json, otherwise YAML.
+// The file is read at the first request, so that Dir can change where it is.
+func NewCassette(t *testing.T, name string, mode CassetteMode) *Cassette {
+	if filepath.Ext(name) == "" {
+		name += ".yaml"
+	}
+	return &Cassette{
+		dir:  CassetteDir,
+		name: name,
+		mode: mode,
+		T:    t,
+	}
+}
+
+// Dir sets the directory of the recording, call it before the first request
+func (c *Cassette) Dir(dir string) *Cassette {
+	c.dir = dir
+	return c
+}
+
+func (c *Cassette) path() string {
+	return filepath.Join(c.dir, c.name)
+}
+
+func (c *Cassette) json() bool {
+	return strings.EqualFold(filepath.Ext(c.name), ".json")
+}
+
+// open resolves CassetteAuto by whether the recording exists and loads it to replay, once
+func (c *Cassette) open() {
+	c.once.Do(func() {
+		if c.mode == CassetteAuto {
+			c.mode = CassetteRecord
+			if _, err := os.Stat(c.path()); err == nil {
+				c.mode = CassetteReplay
+			}
+		}
+		if c.mode == CassetteReplay {
+			c.load()
+		}
+	})
+}
+
+func (c *Cassette) load() {
+	data, err := ioutil.ReadFile(c.path())
+	if !assert.Nil(c.T, err, "cassette %s cannot be replayed", c.path()) {
+		return
+	}
+	var file cassetteFile
+	if c.json() {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	assert.Nil(c.T, err, "cassette %s is malformed", c.path())
+	c.Interactions = file.Interactions
+	c.played = make([]bool, len(c.Interactions))
+}
+
+// Mode returns the mode in effect, CassetteAuto is resolved to CassetteReplay or CassetteRecord
+func (c *Cassette) Mode() CassetteMode {
+	c.open()
+	return c.mode
+}
+
+// RedactHeaders replaces values of headers by CassetteRedacted in the recording
+func (c *Cassette) RedactHeaders(names ...string) *Cassette {
+	c.headers = append(c.headers, names...)
+	return c
+}
+
+// RedactQuery replaces values of query parameters by CassetteRedacted, in the recording and before matching
+func (c *Cassette) RedactQuery(names ...string) *Cassette {
+	c.query = append(c.query, names...)
+	return c
+}
+
+// RedactBody replaces matches of pattern in request and response bodies, in the recording and before matching
+func (c *Cassette) RedactBody(pattern, replacement string) *Cassette {
+	compiled, err := regexp.Compile(pattern)
+	if assert.Nil(c.T, err) {
+		c.bodies = append(c.bodies, bodyRedaction{pattern: compiled, replacement: replacement})
+	}
+	return c
+}
+
+func (c *Cassette) redactURL(rawURL string) string {
+	if len(c.query) == 0 {
+		return rawURL
+	}
+	location, err := http.NewRequest(GET, rawURL, nil)
+	if err != nil {
+		return rawURL
+	}
+	query := location.URL.Query()
+	for _, name := range c.query {
+		if _, exist := query[name]; exist {
+			query.Set(name, CassetteRedacted)
+		}
+	}
+	location.URL.RawQuery = query.Encode()
+	return location.URL.String()
+}
+
+func (c *Cassette) redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range c.headers {
+		values := redacted[http.CanonicalHeaderKey(name)]
+		for i := range values {
+			values[i] = CassetteRedacted
+		}
+	}
+	return redacted
+}
+
+func (c *Cassette) redactBody(body []byte) []byte {
+	for _, redaction := range c.bodies {
+		body = redaction.pattern.ReplaceAll(body, []byte(redaction.replacement))
+	}
+	return body
+}
+
+// encodeBody keeps text bodies readable in the recording, others are base64
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(body, encoding string) []byte {
+	if encoding == "base64" {
+		decoded, _ := base64.StdEncoding.DecodeString(body)
+		return decoded
+	}
+	return []byte(body)
+}
+
+// roundTrip records or replays r
+func (c *Cassette) roundTrip(r *Request) *http.Response {
+	c.open()
+	body := c.redactBody(r.bodyBytes())
+	rawURL := c.redactURL(r.URL.String())
+	if c.mode == CassetteReplay {
+		return c.replay(r, rawURL, body)
+	}
+
+	resp, err := (&http.Client{}).Do(r.Request)
+	if !assert.Nil(r.T, err) {
+		return resp
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(r.T, err)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &Interaction{
+		Request: CassetteRequest{
+			Method: r.Method,
+			URL:    rawURL,
+			Header: c.redactHeader(r.Header),
+		},
+		Response: CassetteResponse{
+			Code:   resp.StatusCode,
+			Header: c.redactHeader(resp.Header),
+		},
+	}
+	interaction.Request.Body, interaction.Request.Encoding = encodeBody(body)
+	interaction.Response.Body, interaction.Response.Encoding = encodeBody(c.redactBody(respBody))
+	c.mu.Lock()
+	c.Interactions = append(c.Interactions, interaction)
+	c.mu.Unlock()
+	return resp
+}
+
+func (c *Cassette) replay(r *Request, rawURL string, body []byte) *http.Response {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, interaction := range c.Interactions {
+		recorded := interaction.Request
+		if c.played[i] || recorded.Method != r.Method || recorded.URL != rawURL || !bytes.Equal(decodeBody(recorded.Body, recorded.Encoding), body) {
+			continue
+		}
+		c.played[i] = true
+		respBody := decodeBody(interaction.Response.Body, interaction.Response.Encoding)
+		header := interaction.Response.Header
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Code, http.StatusText(interaction.Response.Code)),
+			StatusCode:    interaction.Response.Code,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       r.Request,
+		}
+	}
+	assert.Fail(r.T, "unmatched request", "no interaction in %s for %s %s", c.path(), r.Method, rawURL)
+	return &http.Response{
+		Status:     "501 Not Implemented",
+		StatusCode: http.StatusNotImplemented,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		Request:    r.Request,
+	}
+}
+
+// Eject writes the recording in CassetteRecord mode, call it when the test ends
+func (c *Cassette) Eject() {
+	if c.Mode() != CassetteRecord {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var (
+		data []byte
+		err  error
+	)
+	file := cassetteFile{Interactions: c.Interactions}
+	if c.json() {
+		data, err = json.MarshalIndent(file, "", "  ")
+	} else {
+		data, err = yaml.Marshal(file)
+	}
+	if !assert.Nil(c.T, err) {
+		return
+	}
+	if !assert.Nil(c.T, os.MkdirAll(c.dir, 0755)) {
+		return
+	}
+	assert.Nil(c.T, ioutil.WriteFile(c.path(), data, 0644))
+}
+
+// Cassette returns a client whose Request.Send records to or replays from cassette
+func (c Client) Cassette(cassette *Cassette) *Client {
+	c.cassette = cassette
+	return &c
+}
@@ -0,0 +1,71 @@
+package htest
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassette_Replay(t *testing.T) {
+	cassette := NewCassette(t, "example_api", CassetteReplay).RedactQuery("api_key")
+	client := NewClient(t).Cassette(cassette)
+
+	client.Get("https://api.example.com/users/hexi?api_key=secret").
+		Bearer("token").
+		Send().
+		StatusOK().
+		JSON().
+		String("login", "hexi")
+	client.Post("https://api.example.com/users", strings.NewReader(`{"login": "hexi"}`)).
+		Send().
+		StatusCreated().
+		HeaderLocation("https://api.example.com/users/hexi")
+}
+
+func TestCassette_Record(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htest-cassette")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"cassette_record_test.yaml", "cassette_record_test.json"} {
+		path := filepath.Join(dir, name)
+
+		server := httptest.NewServer(Mux)
+		cassette := NewCassette(t, name, CassetteAuto).
+			Dir(dir).
+			RedactHeaders(HeaderAuthorization).
+			RedactQuery("api_key").
+			RedactBody(`"id":\s*\d+`, `"id": 0`)
+		assert.Equal(t, CassetteRecord, cassette.Mode())
+		client := NewClient(t).Cassette(cassette)
+		client.Get(server.URL+"/body/user?api_key=secret").Bearer("token").Send().
+			StatusOK().
+			JSON().
+			String("name", "hexi")
+		client.Get(server.URL + "/body/user").Send().StatusOK()
+		cassette.Eject()
+		server.Close()
+
+		recording, err := ioutil.ReadFile(path)
+		assert.Nil(t, err)
+		assert.NotContains(t, string(recording), "secret")
+		assert.NotContains(t, string(recording), "Bearer token")
+		assert.Contains(t, string(recording), CassetteRedacted)
+
+		cassette = NewCassette(t, name, CassetteAuto).Dir(dir).RedactQuery("api_key")
+		assert.Equal(t, CassetteReplay, cassette.Mode())
+		client = NewClient(t).Cassette(cassette)
+		client.Get(server.URL+"/body/user?api_key=another").Send().
+			StatusOK().
+			JSON().
+			String("name", "hexi").
+			Int("id", 0)
+		client.Get(server.URL + "/body/user").Send().StatusOK()
+	}
+}
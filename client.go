@@ -13,6 +13,9 @@ type (
 	Client struct {
 		handler http.Handler
 		*testing.T
+		digest   *digestAuth
+		cassette *Cassette
+		har      *HAR
 	}
 )
 
@@ -35,6 +38,7 @@ func (c Client) NewRequest(req *http.Request) *Request {
 		Request: req,
 		Handler: c.handler,
 		T:       c.T,
+		client:  &c,
 	}
 }
 
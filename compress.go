@@ -0,0 +1,218 @@
+package htest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+// Content codings
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingBrotli  = "br"
+	EncodingZstd    = "zstd"
+)
+
+type (
+	countingReader struct {
+		io.Reader
+		n int64
+	}
+
+	// decodedBody decodes the content codings of raw lazily, so that streamed bodies stay streamed
+	decodedBody struct {
+		raw       io.ReadCloser
+		counter   *countingReader
+		encodings []string
+		reader    io.Reader
+		closers   []func()
+	}
+)
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// parseEncodings returns content codings of header values in the order they are applied
+func parseEncodings(values []string) []string {
+	var encodings []string
+	for _, value := range values {
+		for _, encoding := range strings.Split(value, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding == "x-gzip" {
+				encoding = EncodingGzip
+			}
+			if encoding != "" && encoding != "identity" {
+				encodings = append(encodings, encoding)
+			}
+		}
+	}
+	return encodings
+}
+
+func supportedEncoding(encoding string) bool {
+	switch encoding {
+	case EncodingGzip, EncodingDeflate, EncodingBrotli, EncodingZstd:
+		return true
+	}
+	return false
+}
+
+func (b *decodedBody) init() error {
+	buffered := bufio.NewReader(b.counter)
+	if _, err := buffered.Peek(1); err == io.EOF {
+		b.reader = buffered
+		return nil
+	}
+	var reader io.Reader = buffered
+	for i := len(b.encodings) - 1; i >= 0; i-- {
+		switch b.encodings[i] {
+		case EncodingGzip:
+			decoder, err := gzip.NewReader(reader)
+			if err != nil {
+				return err
+			}
+			b.closers = append(b.closers, func() { decoder.Close() })
+			reader = decoder
+		case EncodingDeflate:
+			// deflate is zlib wrapped by the RFC, but raw deflate is common in the wild
+			buffered := bufio.NewReader(reader)
+			if header, err := buffered.Peek(2); err == nil && header[0]&0x0f == 8 && (int(header[0])<<8|int(header[1]))%31 == 0 {
+				decoder, err := zlib.NewReader(buffered)
+				if err != nil {
+					return err
+				}
+				b.closers = append(b.closers, func() { decoder.Close() })
+				reader = decoder
+			} else {
+				decoder := flate.NewReader(buffered)
+				b.closers = append(b.closers, func() { decoder.Close() })
+				reader = decoder
+			}
+		case EncodingBrotli:
+			reader = brotli.NewReader(reader)
+		case EncodingZstd:
+			decoder, err := zstd.NewReader(reader)
+			if err != nil {
+				return err
+			}
+			b.closers = append(b.closers, decoder.Close)
+			reader = decoder
+		default:
+			return fmt.Errorf("unsupported content coding %q", b.encodings[i])
+		}
+	}
+	b.reader = reader
+	return nil
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		if err := b.init(); err != nil {
+			return 0, err
+		}
+	}
+	return b.reader.Read(p)
+}
+
+func (b *decodedBody) Close() error {
+	for _, closer := range b.closers {
+		closer()
+	}
+	return b.raw.Close()
+}
+
+// decode replaces the body by its decoded content if it has supported content codings
+func (r *Response) decode() {
+	if r.Response == nil || r.Response.Body == nil {
+		return
+	}
+	r.encodings = parseEncodings(r.Header[HeaderContentEncoding])
+	if len(r.encodings) == 0 {
+		return
+	}
+	for _, encoding := range r.encodings {
+		if !supportedEncoding(encoding) {
+			return
+		}
+	}
+	r.wire = new(bytes.Buffer)
+	r.raw = &countingReader{Reader: io.TeeReader(r.Response.Body, r.wire)}
+	body := &decodedBody{
+		raw:       r.Response.Body,
+		counter:   r.raw,
+		encodings: r.encodings,
+	}
+	r.decoded = &countingReader{Reader: body}
+	// closing the body closes the decoders before the raw body
+	r.Response.Body = &readCloser{Reader: r.decoded, Closer: body}
+	r.Uncompressed = true
+}
+
+// Compressed asserts the body is encoded with encoding,
+// minRatio asserts the decoded size is at least minRatio times the encoded size
+func (r *Response) Compressed(encoding string, minRatio ...float64) *Response {
+	assert.Contains(r.t(), r.encodings, strings.ToLower(encoding), "body is not encoded with %s", encoding)
+	if len(minRatio) == 0 || r.raw == nil {
+		return r
+	}
+	r.peek()
+	if assert.NotZero(r.t(), r.raw.n, "body is empty") {
+		ratio := float64(r.decoded.n) / float64(r.raw.n)
+		assert.True(r.t(), ratio >= minRatio[0], "compression ratio is %.2f, expect at least %.2f", ratio, minRatio[0])
+	}
+	return r
+}
+
+// NotCompressed asserts the body has no content coding
+func (r *Response) NotCompressed() *Response {
+	assert.Empty(r.t(), r.encodings, "body is encoded with %v", r.encodings)
+	return r
+}
+
+func newEncoder(encoding string, w io.Writer) (io.WriteCloser, error) {
+	switch strings.ToLower(encoding) {
+	case EncodingGzip:
+		return gzip.NewWriter(w), nil
+	case EncodingDeflate:
+		return zlib.NewWriter(w), nil
+	case EncodingBrotli:
+		return brotli.NewWriter(w), nil
+	case EncodingZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unsupported content coding %q", encoding)
+}
+
+// Encode compresses the body with encoding and appends it to Content-Encoding, Content-Length is updated
+func (r *Request) Encode(encoding string) *Request {
+	buf := new(bytes.Buffer)
+	encoder, err := newEncoder(encoding, buf)
+	if !assert.Nil(r.T, err) {
+		return r
+	}
+	_, err = encoder.Write(r.bodyBytes())
+	assert.Nil(r.T, err)
+	assert.Nil(r.T, encoder.Close())
+	encodings := append(parseEncodings(r.Header[HeaderContentEncoding]), strings.ToLower(encoding))
+	r.Header.Set(HeaderContentEncoding, strings.Join(encodings, ", "))
+	r.Header.Set(HeaderContentLength, strconv.Itoa(buf.Len()))
+	return r.setBody(buf.Bytes(), "")
+}
+
+func (r *Request) Gzip() *Request {
+	return r.Encode(EncodingGzip)
+}
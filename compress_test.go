@@ -0,0 +1,195 @@
+package htest
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	DecompressLimit = 1 << 10
+)
+
+var (
+	CompressData = fmt.Sprintf(`{"name": "hexi", "padding": "%s"}`, strings.Repeat("htest", 200))
+)
+
+func TestResponse_Compressed(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate, "raw-deflate", EncodingBrotli, EncodingZstd} {
+		response := client.
+			Get("/compress/" + encoding).
+			Test().
+			StatusOK()
+		if encoding == "raw-deflate" {
+			encoding = EncodingDeflate
+		}
+		response.
+			Compressed(encoding, 10).
+			JSON().
+			String("name", "hexi")
+	}
+}
+
+func TestResponse_Compressed_Expect(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/compress/gzip").
+		Test().
+		HeaderContentEncoding(EncodingGzip).
+		Expect(CompressData)
+}
+
+func TestResponse_Compressed_Send(t *testing.T) {
+	server := httptest.NewServer(Mux)
+	defer server.Close()
+	req := NewClient(t).Get(server.URL + "/compress/gzip")
+	req.Send().
+		StatusOK().
+		Compressed(EncodingGzip, 10).
+		JSON().
+		String("name", "hexi")
+	assert.Empty(t, req.Header.Get(HeaderAcceptEncoding))
+	assert.NotContains(t, req.Curl(), HeaderAcceptEncoding)
+}
+
+func TestResponse_Compressed_Empty(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Head("/compress/gzip").
+		Test().
+		Compressed(EncodingGzip).
+		Expect("")
+}
+
+func TestResponse_NotCompressed(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/body/user").
+		Test().
+		NotCompressed()
+}
+
+func TestResponse_Compressed_Close(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		client.Get("/compress/zstd").Test().JSON().String("name", "hexi")
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, runtime.NumGoroutine() < before+5, "%d goroutines before, %d after", before, runtime.NumGoroutine())
+}
+
+func CompressHandler(w http.ResponseWriter, req *http.Request) {
+	encoding := chi.URLParam(req, "encoding")
+	var writer io.WriteCloser
+	switch encoding {
+	case EncodingGzip:
+		writer = gzip.NewWriter(w)
+	case EncodingDeflate:
+		writer = zlib.NewWriter(w)
+	case "raw-deflate":
+		writer, _ = flate.NewWriter(w, flate.DefaultCompression)
+		encoding = EncodingDeflate
+	case EncodingBrotli:
+		writer = brotli.NewWriter(w)
+	case EncodingZstd:
+		writer, _ = zstd.NewWriter(w)
+	}
+	w.Header().Set(HeaderContentEncoding, encoding)
+	if req.Method == HEAD {
+		return
+	}
+	io.WriteString(writer, CompressData)
+	writer.Close()
+}
+
+func TestRequest_Encode(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate, EncodingBrotli, EncodingZstd} {
+		client.
+			Post("/decompress", strings.NewReader(UserData)).
+			Encode(encoding).
+			Test().
+			StatusOK().
+			JSON().
+			String("name", "hexi")
+	}
+}
+
+func TestRequest_Gzip(t *testing.T) {
+	request := NewClient(t).
+		To(Mux).
+		Post("/decompress", strings.NewReader(UserData)).
+		Gzip().
+		Encode(EncodingBrotli)
+	assert.Equal(t, "gzip, br", request.Header.Get(HeaderContentEncoding))
+	assert.Equal(t, strconv.FormatInt(request.ContentLength, 10), request.Header.Get(HeaderContentLength))
+	request.
+		Test().
+		StatusOK().
+		Expect(UserData)
+}
+
+func TestRequest_Gzip_Bomb(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Post("/decompress", bytes.NewReader(make([]byte, 10*DecompressLimit))).
+		Gzip().
+		Test().
+		StatusRequestEntityTooLarge()
+}
+
+// DecompressHandler decodes the request body as a decompression middleware would, up to DecompressLimit bytes.
+// It decodes with the codec packages directly, so that Request.Encode is not checked against decodedBody.
+func DecompressHandler(w http.ResponseWriter, req *http.Request) {
+	var reader io.Reader = req.Body
+	encodings := strings.Split(req.Header.Get(HeaderContentEncoding), ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		switch strings.TrimSpace(encodings[i]) {
+		case EncodingGzip:
+			reader, err = gzip.NewReader(reader)
+		case EncodingDeflate:
+			reader, err = zlib.NewReader(reader)
+		case EncodingBrotli:
+			reader = brotli.NewReader(reader)
+		case EncodingZstd:
+			var decoder *zstd.Decoder
+			if decoder, err = zstd.NewReader(reader); err == nil {
+				defer decoder.Close()
+				reader = decoder
+			}
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(reader, DecompressLimit+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > DecompressLimit {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Write(body)
+}
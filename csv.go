@@ -0,0 +1,151 @@
+package htest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// CSV is a table whose first record is the header, columns are addressed by name
+	CSV struct {
+		body   []byte
+		header []string
+		rows   [][]string
+		exchangeT
+	}
+
+	CSVRow struct {
+		csv    *CSV
+		values []string
+		exchangeT
+	}
+)
+
+func NewCSV(body []byte, t *testing.T) *CSV {
+	return newCSV(body, exchangeT{T: t})
+}
+
+func newCSV(body []byte, t exchangeT) *CSV {
+	c := &CSV{
+		body:      body,
+		exchangeT: t,
+	}
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if assert.Nil(t, err) && len(records) > 0 {
+		c.header, c.rows = records[0], records[1:]
+	}
+	return c
+}
+
+func (r *Response) CSV() *CSV {
+	body, err := ioutil.ReadAll(r.Response.Body)
+	r.Response.Body.Close()
+	assert.Nil(r.t(), err)
+	return newCSV(body, r.t())
+}
+
+func (c *CSV) column(name string) int {
+	for i, column := range c.header {
+		if column == name {
+			return i
+		}
+	}
+	assert.Fail(c.exchangeT, "no column", "no column %q in %v", name, c.header)
+	return -1
+}
+
+func (c *CSV) Header(expect ...string) *CSV {
+	assert.Equal(c.exchangeT, expect, c.header)
+	return c
+}
+
+// Len asserts the number of rows, the header excluded
+func (c *CSV) Len(expect int) *CSV {
+	assert.Equal(c.exchangeT, expect, len(c.rows))
+	return c
+}
+
+// Column asserts all values of column name from top to bottom
+func (c *CSV) Column(name string, expect ...string) *CSV {
+	i := c.column(name)
+	if i < 0 {
+		return c
+	}
+	values := make([]string, 0, len(c.rows))
+	for _, row := range c.rows {
+		values = append(values, row[i])
+	}
+	assert.Equal(c.exchangeT, expect, values)
+	return c
+}
+
+// Row returns the i-th row, the header excluded
+func (c *CSV) Row(i int) *CSVRow {
+	if !assert.True(c.exchangeT, i >= 0 && i < len(c.rows), "no row %d, got %d rows", i, len(c.rows)) {
+		return &CSVRow{csv: c, exchangeT: c.exchangeT}
+	}
+	return &CSVRow{csv: c, values: c.rows[i], exchangeT: c.exchangeT}
+}
+
+// Lookup returns the first row whose value of column keyColumn is key
+func (c *CSV) Lookup(keyColumn, key string) *CSVRow {
+	i := c.column(keyColumn)
+	if i < 0 {
+		return &CSVRow{csv: c, exchangeT: c.exchangeT}
+	}
+	for _, row := range c.rows {
+		if row[i] == key {
+			return &CSVRow{csv: c, values: row, exchangeT: c.exchangeT}
+		}
+	}
+	assert.Fail(c.exchangeT, "no row", "no row whose %s is %q", keyColumn, key)
+	return &CSVRow{csv: c, exchangeT: c.exchangeT}
+}
+
+func (c *CSV) Body() []byte {
+	return c.body
+}
+
+// Records returns all records, the header included
+func (c *CSV) Records() [][]string {
+	if c.header == nil {
+		return nil
+	}
+	return append([][]string{c.header}, c.rows...)
+}
+
+func (r *CSVRow) value(column string) string {
+	i := r.csv.column(column)
+	if i < 0 || r.values == nil {
+		return ""
+	}
+	return r.values[i]
+}
+
+func (r *CSVRow) String(column, expect string) *CSVRow {
+	assert.Equal(r.exchangeT, expect, r.value(column))
+	return r
+}
+
+func (r *CSVRow) Int(column string, expect int64) *CSVRow {
+	value, err := strconv.ParseInt(r.value(column), 10, 64)
+	assert.Nil(r.exchangeT, err)
+	assert.Equal(r.exchangeT, expect, value)
+	return r
+}
+
+func (r *CSVRow) Float(column string, expect float64) *CSVRow {
+	value, err := strconv.ParseFloat(r.value(column), 64)
+	assert.Nil(r.exchangeT, err)
+	assert.Equal(r.exchangeT, expect, value)
+	return r
+}
+
+func (r *CSVRow) Values() []string {
+	return r.values
+}
@@ -0,0 +1,68 @@
+package htest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	CSVAssertData = `id,name,score
+1,hexi,99.5
+2,zhwei,80
+3,"htest, the tester",60
+`
+)
+
+func TestCSV_Assert(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/report/csv").
+		Test().
+		StatusOK().
+		HeaderContentType(MIMETextCSV).
+		CSV().
+		Header("id", "name", "score").
+		Len(3).
+		Column("id", "1", "2", "3").
+		Column("name", "hexi", "zhwei", "htest, the tester")
+}
+
+func TestCSV_Row(t *testing.T) {
+	NewCSV([]byte(CSVAssertData), t).
+		Row(1).
+		Int("id", 2).
+		String("name", "zhwei").
+		Float("score", 80)
+}
+
+func TestCSV_Lookup(t *testing.T) {
+	row := NewCSV([]byte(CSVAssertData), t).
+		Lookup("name", "hexi").
+		Int("id", 1).
+		Float("score", 99.5)
+	assert.Equal(t, []string{"1", "hexi", "99.5"}, row.Values())
+}
+
+func TestCSV_Lookup_NoColumn(t *testing.T) {
+	output := expectFailure(t, "TestCSV_Lookup_NoColumnFailing")
+	assert.Contains(t, output, `no column "nickname"`)
+	assert.NotContains(t, output, "no row whose")
+}
+
+func TestCSV_Lookup_NoColumnFailing(t *testing.T) {
+	failing(t)
+	NewCSV([]byte(CSVAssertData), t).Lookup("nickname", "hexi")
+}
+
+func TestCSV_Records(t *testing.T) {
+	assert.Len(t, NewCSV([]byte(CSVAssertData), t).Records(), 4)
+	assert.Nil(t, NewCSV([]byte(""), t).Records())
+}
+
+func ReportCSVHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(HeaderContentType, MIMETextCSV)
+	io.WriteString(w, CSVAssertData)
+}
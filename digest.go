@@ -0,0 +1,183 @@
+package htest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Digest algorithms, named as in the IANA HTTP Digest Algorithm registry
+const (
+	DigestMD5    = "md5"
+	DigestSHA1   = "sha"
+	DigestSHA256 = "sha-256"
+	DigestSHA512 = "sha-512"
+	DigestCRC32C = "crc32c"
+)
+
+type (
+	Digest struct {
+		algorithm string
+		sum       []byte
+		header    http.Header
+		exchangeT
+	}
+)
+
+func newHash(algorithm string) hash.Hash {
+	switch normalizeDigestAlgorithm(algorithm) {
+	case DigestMD5:
+		return md5.New()
+	case DigestSHA1:
+		return sha1.New()
+	case DigestSHA256:
+		return sha256.New()
+	case DigestSHA512:
+		return sha512.New()
+	case DigestCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	}
+	return nil
+}
+
+func normalizeDigestAlgorithm(algorithm string) string {
+	algorithm = strings.ToLower(algorithm)
+	switch algorithm {
+	case "sha1", "sha-1":
+		return DigestSHA1
+	case "sha256":
+		return DigestSHA256
+	case "sha512":
+		return DigestSHA512
+	}
+	return algorithm
+}
+
+// NewDigest hashes body with algorithm, header is where MatchHeaders looks for advertised digests
+func NewDigest(algorithm string, body io.Reader, header http.Header, t *testing.T) *Digest {
+	return newDigest(algorithm, body, header, exchangeT{T: t})
+}
+
+func newDigest(algorithm string, body io.Reader, header http.Header, t exchangeT) *Digest {
+	d := &Digest{
+		algorithm: normalizeDigestAlgorithm(algorithm),
+		header:    header,
+		exchangeT: t,
+	}
+	h := newHash(algorithm)
+	if !assert.NotNil(t, h, "unsupported digest algorithm %q", algorithm) {
+		return d
+	}
+	_, err := io.Copy(h, body)
+	assert.Nil(t, err)
+	d.sum = h.Sum(nil)
+	return d
+}
+
+// Digest hashes the body as it is on the wire, which is what Content-MD5, Digest and Repr-Digest cover.
+// A content coded body is hashed before it is decoded, the decoded body is kept for reading.
+func (r *Response) Digest(algorithm string) *Digest {
+	return newDigest(algorithm, bytes.NewReader(r.wireBytes()), r.Header, r.t())
+}
+
+func (d *Digest) Sum() []byte {
+	return d.sum
+}
+
+func (d *Digest) Hex() string {
+	return hex.EncodeToString(d.sum)
+}
+
+func (d *Digest) Base64() string {
+	return base64.StdEncoding.EncodeToString(d.sum)
+}
+
+// Uint32 returns a CRC32C checksum as a number
+func (d *Digest) Uint32() uint32 {
+	if !assert.Len(d.exchangeT, d.sum, 4, "%s is not a 32 bits checksum", d.algorithm) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(d.sum)
+}
+
+// Expect compares with the raw binary digest, as MD5.Expect does
+func (d *Digest) Expect(expect string) *Digest {
+	assert.Equal(d.exchangeT, expect, string(d.sum))
+	return d
+}
+
+func (d *Digest) ExpectHex(expect string) *Digest {
+	assert.Equal(d.exchangeT, strings.ToLower(expect), d.Hex())
+	return d
+}
+
+func (d *Digest) ExpectBase64(expect string) *Digest {
+	assert.Equal(d.exchangeT, expect, d.Base64())
+	return d
+}
+
+// ContentMD5 asserts the Content-MD5 header matches, the algorithm must be md5
+func (d *Digest) ContentMD5() *Digest {
+	assert.Equal(d.exchangeT, DigestMD5, d.algorithm, "Content-MD5 is a md5 digest")
+	assert.Equal(d.exchangeT, d.Base64(), d.header.Get(HeaderContentMD5))
+	return d
+}
+
+// DigestHeader asserts the entry of the algorithm in the Digest header (RFC 3230) matches
+func (d *Digest) DigestHeader() *Digest {
+	value, exist := parseDigestHeader(d.header[HeaderDigest], false)[d.algorithm]
+	if assert.True(d.exchangeT, exist, "no %s entry in %s header", d.algorithm, HeaderDigest) {
+		assert.Equal(d.exchangeT, d.Base64(), value)
+	}
+	return d
+}
+
+// ReprDigest asserts the entry of the algorithm in the Repr-Digest header (RFC 9530) matches
+func (d *Digest) ReprDigest() *Digest {
+	value, exist := parseDigestHeader(d.header[HeaderReprDigest], true)[d.algorithm]
+	if assert.True(d.exchangeT, exist, "no %s entry in %s header", d.algorithm, HeaderReprDigest) {
+		assert.Equal(d.exchangeT, d.Base64(), value)
+	}
+	return d
+}
+
+// ETag asserts the entity tag, weak or strong, is the hex or base64 digest
+func (d *Digest) ETag() *Digest {
+	tag := strings.Trim(strings.TrimPrefix(d.header.Get(HeaderETag), "W/"), `"`)
+	if tag != d.Base64() {
+		assert.Equal(d.exchangeT, d.Hex(), strings.ToLower(tag), "ETag is neither the hex nor the base64 %s digest", d.algorithm)
+	}
+	return d
+}
+
+// parseDigestHeader maps algorithms to base64 digests, structured fields wrap digests in colons
+func parseDigestHeader(values []string, structured bool) map[string]string {
+	digests := make(map[string]string)
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			pair := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+			if len(pair) != 2 {
+				continue
+			}
+			digest := strings.TrimSpace(pair[1])
+			if structured {
+				digest = strings.Trim(digest, ":")
+			}
+			digests[normalizeDigestAlgorithm(strings.TrimSpace(pair[0]))] = digest
+		}
+	}
+	return digests
+}
@@ -0,0 +1,94 @@
+package htest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigest_Expect(t *testing.T) {
+	sum := sha256.Sum256([]byte(UserData))
+	NewClient(t).
+		To(Mux).
+		Get("/body/user").
+		Test().
+		StatusOK().
+		Digest(DigestSHA256).
+		Expect(string(sum[:])).
+		ExpectHex(strings.ToUpper(hex.EncodeToString(sum[:]))).
+		ExpectBase64(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+func TestDigest_Algorithms(t *testing.T) {
+	for algorithm, size := range map[string]int{
+		DigestMD5:    16,
+		"SHA-1":      20,
+		DigestSHA256: 32,
+		"sha512":     64,
+		DigestCRC32C: 4,
+	} {
+		assert.Len(t, NewDigest(algorithm, strings.NewReader(UserData), nil, t).Sum(), size, algorithm)
+	}
+	checksum := crc32.Checksum([]byte(UserData), crc32.MakeTable(crc32.Castagnoli))
+	assert.Equal(t, checksum, NewDigest(DigestCRC32C, strings.NewReader(UserData), nil, t).Uint32())
+}
+
+func TestDigest_Headers(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.Get("/artifact").Test().StatusOK().Digest(DigestMD5).ContentMD5()
+	client.Get("/artifact").Test().StatusOK().Digest(DigestMD5).DigestHeader()
+	client.Get("/artifact").Test().StatusOK().Digest(DigestSHA256).DigestHeader()
+	client.Get("/artifact").Test().StatusOK().Digest(DigestSHA256).ReprDigest()
+	client.Get("/artifact").Test().StatusOK().Digest(DigestSHA256).ETag()
+}
+
+func TestMD5_ExpectHex(t *testing.T) {
+	sum := md5.Sum([]byte(UserData))
+	NewClient(t).
+		To(Mux).
+		Get("/body/user").
+		Test().
+		MD5().
+		ExpectHex(hex.EncodeToString(sum[:])).
+		ExpectBase64(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+func TestDigest_Headers_Compressed(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	response := client.Get("/artifact?encoding=gzip").Test().StatusOK()
+	response.Digest(DigestMD5).ContentMD5().DigestHeader()
+	response.Digest(DigestSHA256).DigestHeader().ReprDigest().ETag()
+	response.Compressed(EncodingGzip).Expect(FileData)
+}
+
+// ArtifactHandler advertises digests of the body as it is sent, gzip encoded with ?encoding=gzip
+func ArtifactHandler(w http.ResponseWriter, req *http.Request) {
+	body := []byte(FileData)
+	if req.URL.Query().Get("encoding") == EncodingGzip {
+		buf := new(bytes.Buffer)
+		writer := gzip.NewWriter(buf)
+		writer.Write(body)
+		writer.Close()
+		body = buf.Bytes()
+		w.Header().Set(HeaderContentEncoding, EncodingGzip)
+	}
+	md5Sum := md5.Sum(body)
+	sha256Sum := sha256.Sum256(body)
+	md5Base64 := base64.StdEncoding.EncodeToString(md5Sum[:])
+	sha256Base64 := base64.StdEncoding.EncodeToString(sha256Sum[:])
+	w.Header().Set(HeaderContentMD5, md5Base64)
+	w.Header().Set(HeaderDigest, fmt.Sprintf("MD5=%s, SHA-256=%s", md5Base64, sha256Base64))
+	w.Header().Set(HeaderReprDigest, fmt.Sprintf("sha-256=:%s:", sha256Base64))
+	w.Header().Set(HeaderETag, fmt.Sprintf(`W/"%x"`, sha256Sum))
+	w.Write(body)
+}
@@ -0,0 +1,238 @@
+package htest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	// DumpBodyLimit is how many bytes of a body a failure message shows
+	DumpBodyLimit = 4096
+)
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// absoluteURL is the url of r, a request to the mock server is addressed to localhost
+func (r *Request) absoluteURL() string {
+	location := *r.URL
+	if location.Host == "" {
+		location.Host = r.Host
+		if location.Host == "" {
+			location.Host = "localhost"
+		}
+	}
+	if location.Scheme == "" {
+		location.Scheme = "http"
+	}
+	return location.String()
+}
+
+// peekBody returns the body without consuming it, a sent body is replayed by GetBody
+func (r *Request) peekBody() []byte {
+	if r.GetBody != nil {
+		if reader, err := r.GetBody(); err == nil {
+			body, _ := ioutil.ReadAll(reader)
+			return body
+		}
+	}
+	return r.bodyBytes()
+}
+
+// sentBody returns the body of a sent request if it can be replayed, kept is false for a body which is gone
+func (r *Request) sentBody() (body []byte, kept bool) {
+	if r.GetBody == nil {
+		return nil, r.Body == nil || r.Body == http.NoBody
+	}
+	reader, err := r.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	body, err = ioutil.ReadAll(reader)
+	return body, err == nil
+}
+
+// Curl returns an equivalent curl command line, the body is kept for sending
+func (r *Request) Curl() string {
+	return r.curl(r.peekBody())
+}
+
+func (r *Request) curl(body []byte) string {
+	parts := []string{"curl", "-X", r.Method, shellQuote(r.absoluteURL())}
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			parts = append(parts, "-H", shellQuote(name+": "+value))
+		}
+	}
+	if len(body) > 0 {
+		parts = append(parts, "--data-binary", shellQuote(string(body)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Dump returns the request in HTTP/1.1 wire format, the body is kept for sending
+func (r *Request) Dump() string {
+	return r.dump(r.peekBody())
+}
+
+func (r *Request) dump(body []byte) string {
+	dump, err := httputil.DumpRequest(r.Request, false)
+	if err != nil {
+		return err.Error()
+	}
+	return string(dump) + string(body)
+}
+
+// Dump returns the response in HTTP/1.1 wire format with the decoded body, the body is kept for reading.
+// At most DumpBodyLimit bytes of the body are shown. A body of unknown length on the wire may be a stream
+// without end, it is not waited for: it shows as much as has been read.
+func (r *Response) Dump() string {
+	resp := *r.Response
+	var body []byte
+	note := ""
+	if r.inMemory || r.ContentLength >= 0 {
+		var more bool
+		if body, more = r.peekLimit(DumpBodyLimit); more {
+			note = "\n... more bytes"
+		} else {
+			resp.ContentLength = int64(len(body))
+		}
+	} else if r.captured != nil {
+		var n int
+		if body, n = r.captured.dump(); n > len(body) {
+			note = fmt.Sprintf("\n... %d more bytes", n-len(body))
+		}
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(nil))
+	dump, err := httputil.DumpResponse(&resp, false)
+	if err != nil {
+		return err.Error()
+	}
+	return string(dump) + string(body) + note
+}
+
+func truncateDump(dump string) string {
+	if len(dump) <= DumpBodyLimit {
+		return dump
+	}
+	return fmt.Sprintf("%s\n... %d more bytes", dump[:DumpBodyLimit], len(dump)-DumpBodyLimit)
+}
+
+type (
+	// capturedBody keeps the first limit bytes read from a response body for failure messages and archives,
+	// the body may be read by another goroutine such as the reader of an SSE
+	capturedBody struct {
+		io.ReadCloser
+		mu       sync.Mutex
+		captured bytes.Buffer
+		n        int
+		limit    int
+	}
+
+	// exchangeT fails assertions on a Response or its body with the exchange appended to the message,
+	// if tests run in verbose mode. A body made by NewJSON and the like has no exchange
+	exchangeT struct {
+		*testing.T
+		response *Response
+	}
+)
+
+func (b *capturedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - b.captured.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.captured.Write(p[:room])
+	}
+	b.n += n
+	return n, err
+}
+
+// read returns a copy of the bytes captured and how many bytes are read
+func (b *capturedBody) read() ([]byte, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.captured.Bytes()...), b.n
+}
+
+// dump is read with at most DumpBodyLimit bytes
+func (b *capturedBody) dump() ([]byte, int) {
+	body, n := b.read()
+	if len(body) > DumpBodyLimit {
+		body = body[:DumpBodyLimit]
+	}
+	return body, n
+}
+
+func (t exchangeT) Errorf(format string, args ...interface{}) {
+	t.Helper()
+	if t.response == nil || !testing.Verbose() {
+		t.T.Errorf(format, args...)
+		return
+	}
+	t.T.Errorf(format+"\n\n%s", append(args, t.response.failureDump())...)
+}
+
+// t is the T of assertions on r and its body, so that a failure shows the request and response it is about
+func (r *Response) t() exchangeT {
+	return exchangeT{T: r.T, response: r}
+}
+
+// failureDump is the exchange of r for a failure message. Bodies are not read for it, except a response of
+// the mock server which is in memory; a streamed body shows as much as has been read.
+func (r *Response) failureDump() string {
+	var parts []string
+	if req := r.request; req != nil && req.Request != nil {
+		body, kept := req.sentBody()
+		dump := req.dump(body)
+		if !kept {
+			dump += "[body is not kept]"
+		}
+		parts = append(parts, req.curl(body), truncateDump(dump))
+	}
+	if r.Response == nil {
+		return strings.Join(parts, "\n\n")
+	}
+
+	var body []byte
+	note := ""
+	if b := r.captured; b != nil {
+		if r.inMemory {
+			rest, _ := ioutil.ReadAll(r.Response.Body)
+			r.Response.Body = ioutil.NopCloser(bytes.NewReader(rest))
+		}
+		var n int
+		body, n = b.dump()
+		switch {
+		case n > len(body):
+			note = fmt.Sprintf("\n... %d more bytes", n-len(body))
+		case n == 0 && !r.inMemory:
+			note = "[body is not read]"
+		}
+	}
+	resp := *r.Response
+	resp.Body = ioutil.NopCloser(bytes.NewReader(nil))
+	dump, err := httputil.DumpResponse(&resp, false)
+	if err != nil {
+		return strings.Join(append(parts, err.Error()), "\n\n")
+	}
+	return strings.Join(append(parts, string(dump)+string(body)+note), "\n\n")
+}
@@ -0,0 +1,101 @@
+package htest
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest_Curl(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	req := client.Post("/client/post", strings.NewReader(`{"name": "it's me"}`)).
+		SetHeader(HeaderContentType, MIMEApplicationJSON).
+		Bearer("token")
+	assert.Equal(t,
+		`curl -X POST 'http://localhost/client/post' -H 'Authorization: Bearer token' -H 'Content-Type: application/json' --data-binary '{"name": "it'\''s me"}'`,
+		req.Curl())
+	// the body is still sent
+	req.Test().StatusOK()
+
+	assert.Equal(t, `curl -X GET 'https://api.example.com/users?page=2'`, client.Get("https://api.example.com/users?page=2").Curl())
+}
+
+func TestRequest_Dump(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	req := client.Post("/client/post", strings.NewReader(UserData)).SetHeader(HeaderContentType, MIMEApplicationJSON)
+	dump := req.Dump()
+	assert.True(t, strings.HasPrefix(dump, "POST /client/post HTTP/1.1\r\n"), dump)
+	assert.Contains(t, dump, "Content-Type: application/json\r\n")
+	assert.True(t, strings.HasSuffix(dump, "\r\n\r\n"+UserData), dump)
+	req.Test().StatusOK()
+}
+
+func TestResponse_Dump(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	response := client.Get("/compress/gzip").Test()
+	dump := response.Dump()
+	assert.True(t, strings.HasPrefix(dump, "HTTP/1.1 200 OK\r\n"), dump)
+	assert.Contains(t, dump, "Content-Encoding: gzip\r\n")
+	assert.True(t, strings.HasSuffix(dump, CompressData), dump)
+	assert.Equal(t, CompressData, response.String())
+}
+
+func TestResponse_FailureDump(t *testing.T) {
+	output := expectFailure(t, "TestResponse_FailureDumpFailing")
+	assert.Contains(t, output, `curl -X POST 'http://localhost/client/post' -H 'Content-Type: application/json' --data-binary '{"id": 0}'`)
+	assert.Contains(t, output, "POST /client/post HTTP/1.1")
+	assert.Contains(t, output, "HTTP/1.1 200 OK")
+	assert.Contains(t, output, `"name": "hexi"`, "a mock server response is dumped unread")
+	assert.Contains(t, output, "[body is not kept]", "a body which cannot be replayed is not buffered, even with -v")
+	assert.Contains(t, output, "event: ping", "a streamed body shows what is read")
+	assert.Contains(t, output, "[body is not read]", "a streamed body is not drained")
+	assert.Contains(t, output, "GET /body/user HTTP/1.1", "a failure on the body shows the exchange")
+	assert.Contains(t, output, "GET /auth/digest HTTP/1.1", "a failure on a header shows the exchange")
+}
+
+func TestResponse_FailureDump_NotVerbose(t *testing.T) {
+	output := expectFailure(t, "TestResponse_FailureDumpFailing", "-test.v=false")
+	assert.NotContains(t, output, "POST /client/post HTTP/1.1")
+	assert.NotContains(t, output, "curl")
+}
+
+func TestResponse_FailureDumpFailing(t *testing.T) {
+	failing(t)
+	client := NewClient(t).To(Mux)
+	client.Post("/client/post", strings.NewReader(`{"id": 0}`)).
+		SetHeader(HeaderContentType, MIMEApplicationJSON).
+		Test().
+		StatusNotFound()
+
+	req := client.Post("/client/post", io.MultiReader(strings.NewReader(UserData)))
+	req.Test().StatusNotFound()
+	assert.Nil(t, req.GetBody)
+
+	stream := client.Get("/sse/forever").Stream()
+	defer stream.Body.Close()
+	buf := make([]byte, len("event: ping"))
+	io.ReadFull(stream.Body, buf)
+	stream.StatusNotFound()
+	client.Get("/sse/forever").Stream().StatusNotFound().Body.Close()
+
+	client.Get("/body/user").Test().JSON().String("name", "nobody")
+	client.Get("/auth/digest").Test().WWWAuthenticate().Scheme("Basic")
+}
+
+func TestResponse_Dump_Stream(t *testing.T) {
+	server := httptest.NewServer(Mux)
+	defer server.Close()
+	response := NewClient(t).Get(server.URL + "/ndjson/forever").Send()
+	dump := response.Dump()
+	assert.True(t, strings.HasPrefix(dump, "HTTP/1.1 200 OK\r\n"), dump)
+	assert.True(t, strings.HasSuffix(dump, "\r\n\r\n"), "an endless body is not waited for: %s", dump)
+
+	lines := response.JSONLines()
+	lines.At(2).Int("id", 3)
+	assert.True(t, strings.HasSuffix(response.Dump(), NDJSONData), "a body shows what is read")
+	lines.Close()
+	<-NDJSONDisconnected
+}
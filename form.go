@@ -0,0 +1,218 @@
+package htest
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// FormValues is an application/x-www-form-urlencoded body
+	FormValues struct {
+		url.Values
+		body []byte
+		exchangeT
+	}
+
+	// Multipart is a multipart body, parts are read in order
+	Multipart struct {
+		parts []*Part
+		exchangeT
+	}
+
+	Part struct {
+		Header textproto.MIMEHeader
+		body   []byte
+		exchangeT
+	}
+)
+
+func NewFormValues(body []byte, t *testing.T) *FormValues {
+	return newFormValues(body, exchangeT{T: t})
+}
+
+func newFormValues(body []byte, t exchangeT) *FormValues {
+	values, err := url.ParseQuery(string(body))
+	assert.Nil(t, err)
+	return &FormValues{
+		Values:    values,
+		body:      body,
+		exchangeT: t,
+	}
+}
+
+func (r *Response) Form() *FormValues {
+	body, err := ioutil.ReadAll(r.Response.Body)
+	r.Response.Body.Close()
+	assert.Nil(r.t(), err)
+	return newFormValues(body, r.t())
+}
+
+func (f *FormValues) Exist(key string) *FormValues {
+	_, exist := f.Values[key]
+	assert.True(f.exchangeT, exist, "form key %q does not exist", key)
+	return f
+}
+
+func (f *FormValues) NotExist(key string) *FormValues {
+	_, exist := f.Values[key]
+	assert.False(f.exchangeT, exist, "form key %q exists", key)
+	return f
+}
+
+func (f *FormValues) String(key, expect string) *FormValues {
+	assert.Equal(f.exchangeT, expect, f.Get(key))
+	return f
+}
+
+// Strings asserts all values of key in order
+func (f *FormValues) Strings(key string, expect ...string) *FormValues {
+	assert.Equal(f.exchangeT, expect, f.Values[key])
+	return f
+}
+
+func (f *FormValues) Int(key string, expect int64) *FormValues {
+	value, err := strconv.ParseInt(f.Get(key), 10, 64)
+	assert.Nil(f.exchangeT, err)
+	assert.Equal(f.exchangeT, expect, value)
+	return f
+}
+
+func (f *FormValues) Body() []byte {
+	return f.body
+}
+
+// NewMultipart reads all parts of body, which is delimited by boundary
+func NewMultipart(body io.Reader, boundary string, t *testing.T) *Multipart {
+	return newMultipart(body, boundary, exchangeT{T: t})
+}
+
+func newMultipart(body io.Reader, boundary string, t exchangeT) *Multipart {
+	m := &Multipart{exchangeT: t}
+	reader := multipart.NewReader(body, boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if !assert.Nil(t, err) {
+			break
+		}
+		data, err := ioutil.ReadAll(part)
+		assert.Nil(t, err)
+		m.parts = append(m.parts, &Part{Header: part.Header, body: data, exchangeT: t})
+	}
+	return m
+}
+
+// Multipart reads a multipart body, the boundary is taken from Content-Type
+func (r *Response) Multipart() *Multipart {
+	defer r.Response.Body.Close()
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get(HeaderContentType))
+	assert.Nil(r.t(), err)
+	assert.True(r.t(), strings.HasPrefix(mediaType, "multipart/"), "%q is not a multipart type", mediaType)
+	return newMultipart(r.Response.Body, params["boundary"], r.t())
+}
+
+func (m *Multipart) Len(expect int) *Multipart {
+	assert.Equal(m.exchangeT, expect, len(m.parts))
+	return m
+}
+
+func (m *Multipart) Each(fn func(i int, part *Part)) *Multipart {
+	for i, part := range m.parts {
+		fn(i, part)
+	}
+	return m
+}
+
+func (m *Multipart) Part(i int) *Part {
+	if !assert.True(m.exchangeT, i >= 0 && i < len(m.parts), "no part %d, got %d parts", i, len(m.parts)) {
+		return &Part{Header: make(textproto.MIMEHeader), exchangeT: m.exchangeT}
+	}
+	return m.parts[i]
+}
+
+// Named returns the first part whose form name is name
+func (m *Multipart) Named(name string) *Part {
+	for _, part := range m.parts {
+		if part.FormName() == name {
+			return part
+		}
+	}
+	assert.Fail(m.exchangeT, "no part", "no part named %q", name)
+	return &Part{Header: make(textproto.MIMEHeader), exchangeT: m.exchangeT}
+}
+
+func (p *Part) disposition() map[string]string {
+	_, params, _ := mime.ParseMediaType(p.Header.Get(HeaderContentDisposition))
+	return params
+}
+
+func (p *Part) FormName() string {
+	return p.disposition()["name"]
+}
+
+func (p *Part) FileName() string {
+	return p.disposition()["filename"]
+}
+
+func (p *Part) Headers(key, expect string) *Part {
+	assert.Equal(p.exchangeT, expect, p.Header.Get(key))
+	return p
+}
+
+func (p *Part) ContentType(expect string) *Part {
+	return p.Headers(HeaderContentType, expect)
+}
+
+func (p *Part) Name(expect string) *Part {
+	assert.Equal(p.exchangeT, expect, p.FormName())
+	return p
+}
+
+func (p *Part) File(expect string) *Part {
+	assert.Equal(p.exchangeT, expect, p.FileName())
+	return p
+}
+
+func (p *Part) Expect(expect string) *Part {
+	assert.Equal(p.exchangeT, expect, string(p.body))
+	return p
+}
+
+func (p *Part) Bytes() []byte {
+	return p.body
+}
+
+func (p *Part) String() string {
+	return string(p.body)
+}
+
+func (p *Part) JSON() *JSON {
+	return newJSON(p.body, p.exchangeT)
+}
+
+func (p *Part) XML() *XML {
+	return newXML(p.body, p.exchangeT)
+}
+
+func (p *Part) MD5() *MD5 {
+	sum := md5.Sum(p.body)
+	return newMD5(sum[:], p.exchangeT)
+}
+
+func (p *Part) SHA1() *SHA1 {
+	sum := sha1.Sum(p.body)
+	return newSHA1(sum[:], p.exchangeT)
+}
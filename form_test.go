@@ -0,0 +1,99 @@
+package htest
+
+import (
+	"crypto/md5"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	FileData = "hello, htest"
+)
+
+func TestResponse_Form(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Post("/form/token", strings.NewReader("grant_type=client_credentials")).
+		SetHeader(HeaderContentType, MIMEApplicationForm).
+		Test().
+		StatusOK().
+		HeaderContentType(MIMEApplicationForm).
+		Form().
+		Exist("access_token").
+		NotExist("refresh_token").
+		String("token_type", "bearer").
+		Int("expires_in", 3600).
+		Strings("scope", "read", "write")
+}
+
+func TestResponse_Multipart(t *testing.T) {
+	var names []string
+	NewClient(t).
+		To(Mux).
+		Get("/multipart/batch").
+		Test().
+		StatusOK().
+		Multipart().
+		Len(2).
+		Each(func(i int, part *Part) {
+			names = append(names, part.FormName())
+		})
+	assert.Equal(t, []string{"user", "file"}, names)
+}
+
+func TestPart_Assert(t *testing.T) {
+	batch := NewClient(t).
+		To(Mux).
+		Get("/multipart/batch").
+		Test().
+		Multipart()
+	batch.Part(0).
+		Name("user").
+		ContentType(MIMEApplicationJSON).
+		JSON().
+		String("name", "hexi")
+	fileMD5 := md5.Sum([]byte(FileData))
+	batch.Named("file").
+		File("hello.txt").
+		ContentType(MIMETextPlain).
+		Expect(FileData).
+		MD5().
+		Expect(string(fileMD5[:]))
+}
+
+func FormTokenHandler(w http.ResponseWriter, req *http.Request) {
+	if req.PostFormValue("grant_type") != "client_credentials" {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set(HeaderContentType, MIMEApplicationForm)
+	io.WriteString(w, url.Values{
+		"access_token": {"token"},
+		"token_type":   {"bearer"},
+		"expires_in":   {"3600"},
+		"scope":        {"read", "write"},
+	}.Encode())
+}
+
+func MultipartBatchHandler(w http.ResponseWriter, req *http.Request) {
+	writer := multipart.NewWriter(w)
+	w.Header().Set(HeaderContentType, "multipart/mixed; boundary="+writer.Boundary())
+	part, _ := writer.CreatePart(textproto.MIMEHeader{
+		HeaderContentType:        {MIMEApplicationJSON},
+		HeaderContentDisposition: {`form-data; name="user"`},
+	})
+	io.WriteString(part, UserData)
+	part, _ = writer.CreatePart(textproto.MIMEHeader{
+		HeaderContentType:        {MIMETextPlain},
+		HeaderContentDisposition: {`form-data; name="file"; filename="hello.txt"`},
+	})
+	io.WriteString(part, FileData)
+	writer.Close()
+}
@@ -1,23 +1,34 @@
 module github.com/zhwei820/htest
 
 require (
+	github.com/BurntSushi/toml v0.3.1
+	github.com/andybalholm/brotli v1.0.4
+	github.com/andybalholm/cascadia v1.1.0
 	github.com/basgys/goxml2json v1.1.0
 	github.com/bitly/go-simplejson v0.5.0 // indirect
 	github.com/bmizerany/assert v0.0.0-20160611221934-b7ed37b82869 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/go-chi/chi v3.3.2+incompatible
 	github.com/gogf/gf v1.14.5
+	github.com/google/go-cmp v0.5.5
+	github.com/gorilla/websocket v1.4.1
+	github.com/klauspost/compress v1.11.13
 	github.com/kr/pretty v0.2.0 // indirect
 	github.com/labstack/echo v0.0.0-20171223171103-b338075a0fc6
 	github.com/labstack/gommon v0.0.0-20170925052817-57409ada9da0 // indirect
 	github.com/mattn/go-colorable v0.0.0-20180115155639-6cc8b475d468 // indirect
 	github.com/mattn/go-isatty v0.0.4 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.3.0
+	github.com/stretchr/testify v1.6.1
 	github.com/tidwall/gjson v1.1.0
 	github.com/tidwall/match v0.0.0-20171002075945-1731857f09b1 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasttemplate v0.0.0-20170224212429-dcecefd839c4 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	github.com/xeipuuv/gojsonschema v1.2.0
+	golang.org/x/net v0.0.0-20200602114024-627f9648deb9
+	google.golang.org/protobuf v1.28.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 go 1.13
@@ -0,0 +1,301 @@
+package htest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// HARBodyLimit is how many bytes of a response body an archive keeps
+	HARBodyLimit = 1 << 20
+)
+
+// HAR modes
+const (
+	// HAROnFailure writes the archive only if the test fails
+	HAROnFailure HARMode = iota
+	// HARAlways writes the archive when it is closed
+	HARAlways
+)
+
+type (
+	HARMode int
+
+	// HAR records every Request.Test, Request.Send and Request.Stream exchange of a client into a HTTP Archive 1.2,
+	// see http://www.softwareishard.com/blog/har-12-spec/. A body of the mock server is archived at once,
+	// other bodies are not waited for: they are archived as far as the test reads them before Close
+	HAR struct {
+		path    string
+		mode    HARMode
+		entries []harEntry
+		mu      sync.Mutex
+		*testing.T
+	}
+
+	harLog struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+		Comment string     `json:"comment,omitempty"`
+	}
+
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+
+		// body is read by the test, its content is archived by Close
+		body *capturedBody
+	}
+
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harCookie    `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harCookie    `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harCookie struct {
+		Name     string `json:"name"`
+		Value    string `json:"value"`
+		Path     string `json:"path,omitempty"`
+		Domain   string `json:"domain,omitempty"`
+		Expires  string `json:"expires,omitempty"`
+		HTTPOnly bool   `json:"httpOnly,omitempty"`
+		Secure   bool   `json:"secure,omitempty"`
+	}
+
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Encoding string `json:"encoding,omitempty"`
+		Comment  string `json:"comment,omitempty"`
+	}
+
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+// NewHAR archives to path, Close writes the file according to mode
+func NewHAR(t *testing.T, path string, mode HARMode) *HAR {
+	return &HAR{
+		path: path,
+		mode: mode,
+		T:    t,
+	}
+}
+
+// HAR returns a client whose exchanges are archived by har
+func (c Client) HAR(har *HAR) *Client {
+	c.har = har
+	return &c
+}
+
+// Len returns the number of archived exchanges
+func (h *HAR) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// Close writes the archive, with HAROnFailure only if the test has failed
+func (h *HAR) Close() {
+	if h.mode == HAROnFailure && !h.Failed() {
+		return
+	}
+	h.mu.Lock()
+	for i := range h.entries {
+		h.entries[i].readContent()
+	}
+	data, err := json.MarshalIndent(map[string]harLog{"log": {
+		Version: "1.2",
+		Creator: harCreator{Name: "htest", Version: "1.0"},
+		Entries: h.entries,
+		Comment: h.Name(),
+	}}, "", "  ")
+	h.mu.Unlock()
+	if !assert.Nil(h.T, err) {
+		return
+	}
+	if dir := filepath.Dir(h.path); dir != "" {
+		assert.Nil(h.T, os.MkdirAll(dir, 0755))
+	}
+	if assert.Nil(h.T, ioutil.WriteFile(h.path, data, 0644)) && h.Failed() {
+		h.Logf("exchanges are archived in %s", h.path)
+	}
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	pairs := make([]harNameValue, 0, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+func harCookies(cookies []*http.Cookie) []harCookie {
+	result := make([]harCookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		c := harCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			c.Expires = cookie.Expires.Format(time.RFC3339)
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// setText sets the text of c to body, base64 encoded unless it is utf-8
+func (c *harContent) setText(body []byte) {
+	if utf8.Valid(body) {
+		c.Text, c.Encoding = string(body), ""
+	} else {
+		c.Text, c.Encoding = base64.StdEncoding.EncodeToString(body), "base64"
+	}
+}
+
+// readContent archives what the test has read from a body which is not in memory
+func (e *harEntry) readContent() {
+	b := e.body
+	if b == nil {
+		return
+	}
+	content := &e.Response.Content
+	body, n := b.read()
+	content.setText(body)
+	content.Size = n
+	switch {
+	case n > len(body):
+		content.Comment = fmt.Sprintf("body is truncated to %d bytes", len(body))
+	case n == 0:
+		content.Comment = "body is not read"
+	default:
+		content.Comment = ""
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// archive adds the exchange of r to the HAR of its client, the response body is kept for later reads
+func (r *Request) archive(started time.Time, response *Response) {
+	if r.client == nil || r.client.har == nil || response == nil || response.Response == nil {
+		return
+	}
+	elapsed := time.Since(started)
+	entry := harEntry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            milliseconds(elapsed),
+		Timings:         harTimings{Wait: milliseconds(elapsed)},
+	}
+
+	query := make([]harNameValue, 0)
+	for name, values := range r.URL.Query() {
+		for _, value := range values {
+			query = append(query, harNameValue{Name: name, Value: value})
+		}
+	}
+	entry.Request = harRequest{
+		Method:      r.Method,
+		URL:         r.URL.String(),
+		HTTPVersion: r.Proto,
+		Cookies:     harCookies(r.Cookies()),
+		Headers:     harHeaders(r.Header),
+		QueryString: query,
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	if body := r.peekBody(); len(body) > 0 {
+		entry.Request.BodySize = len(body)
+		entry.Request.PostData = &harPostData{MimeType: r.Header.Get(HeaderContentType), Text: string(body)}
+	}
+
+	content := harContent{Size: -1, MimeType: response.Header.Get(HeaderContentType)}
+	if response.inMemory {
+		body, more := response.peekLimit(HARBodyLimit)
+		content.setText(body)
+		content.Size = len(body)
+		if more {
+			content.Comment = fmt.Sprintf("body is truncated to %d bytes", len(body))
+		}
+	} else {
+		// a body on the wire may be a stream without end, it is not waited for
+		entry.body = response.captured
+	}
+	entry.Response = harResponse{
+		Status:      response.StatusCode,
+		StatusText:  http.StatusText(response.StatusCode),
+		HTTPVersion: fmt.Sprintf("HTTP/%d.%d", response.ProtoMajor, response.ProtoMinor),
+		Cookies:     harCookies(response.Cookies()),
+		Headers:     harHeaders(response.Header),
+		Content:     content,
+		RedirectURL: response.Header.Get(HeaderLocation),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+
+	har := r.client.har
+	har.mu.Lock()
+	har.entries = append(har.entries, entry)
+	har.mu.Unlock()
+}
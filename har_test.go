@@ -0,0 +1,95 @@
+package htest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHAR(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "always", "run.har")
+	har := NewHAR(t, path, HARAlways)
+	client := NewClient(t).To(Mux).HAR(har)
+	client.Get("/request/cookie").AddCookie(&testCookie).Test().StatusOK()
+	client.Post("/html/login", strings.NewReader("user=hexi&password=secret")).
+		SetHeader(HeaderContentType, MIMEApplicationForm).
+		Test()
+	client.Get("/compress/gzip").Test().StatusOK()
+	assert.Equal(t, 3, har.Len())
+	har.Close()
+
+	archive, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	NewJSON(archive, t).
+		String("log.version", "1.2").
+		String("log.creator.name", "htest").
+		String("log.comment", t.Name()).
+		Int("log.entries.#", 3).
+		String("log.entries.0.request.method", GET).
+		String("log.entries.0.request.url", "/request/cookie").
+		String("log.entries.0.request.cookies.0.name", testCookie.Name).
+		Int("log.entries.0.response.status", http.StatusOK).
+		String("log.entries.1.request.postData.mimeType", MIMEApplicationForm).
+		String("log.entries.1.request.postData.text", "user=hexi&password=secret").
+		Int("log.entries.1.request.bodySize", 25).
+		Exist("log.entries.1.timings.wait").
+		Exist("log.entries.2.response.content.text").
+		String(`log.entries.2.response.headers.#[name=="Content-Encoding"].value`, EncodingGzip)
+
+	path = filepath.Join(dir, "digest.har")
+	har = NewHAR(t, path, HARAlways)
+	NewClient(t).To(Mux).DigestAuth(AuthUser, AuthPass).HAR(har).Get("/auth/digest").Test().StatusOK()
+	assert.Equal(t, 2, har.Len(), "the challenge and the retry are archived")
+	har.Close()
+	archive, err = ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	NewJSON(archive, t).
+		Int("log.entries.#", 2).
+		Int("log.entries.0.response.status", http.StatusUnauthorized).
+		Exist("log.entries.0.response.headers").
+		NotExist(`log.entries.0.request.headers.#[name=="Authorization"]`).
+		Int("log.entries.1.response.status", http.StatusOK).
+		Exist(`log.entries.1.request.headers.#[name=="Authorization"]`)
+
+	path = filepath.Join(dir, "stream.har")
+	har = NewHAR(t, path, HARAlways)
+	server := httptest.NewServer(Mux)
+	defer server.Close()
+	client = NewClient(t).HAR(har)
+	lines := client.Get(server.URL + "/ndjson/forever").Send().StatusOK().JSONLines()
+	lines.At(0).Int("id", 1)
+	lines.Close()
+	<-NDJSONDisconnected
+	client.Get(server.URL + "/name").Send().StatusOK()
+	stream := client.To(Mux).Get("/sse/forever").Stream().StatusOK()
+	stream.SSE().ExpectEvent("ping", "1").Close()
+	<-SSEDisconnected
+	assert.Equal(t, 3, har.Len(), "streams are archived without waiting for their end")
+	har.Close()
+	archive, err = ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	NewJSON(archive, t).
+		Int("log.entries.#", 3).
+		Int("log.entries.0.response.content.size", int64(len(NDJSONData))).
+		String("log.entries.0.response.content.text", NDJSONData).
+		String("log.entries.1.response.content.comment", "body is not read").
+		String("log.entries.2.request.url", "/sse/forever").
+		String("log.entries.2.response.content.text", "event: ping\ndata: 1\n\n")
+
+	path = filepath.Join(dir, "failure.har")
+	har = NewHAR(t, path, HAROnFailure)
+	NewClient(t).To(Mux).HAR(har).Get("/name").Test().StatusOK()
+	har.Close()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "archive is written without failure")
+}
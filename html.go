@@ -0,0 +1,270 @@
+package htest
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+type (
+	HTML struct {
+		body []byte
+		root *html.Node
+		exchangeT
+	}
+
+	// Form is a snapshot of a html form, fields can be overridden before Submit
+	Form struct {
+		Action  string
+		Method  string
+		Enctype string
+		Fields  url.Values
+		html    *HTML
+		exchangeT
+	}
+)
+
+func NewHTML(body []byte, t *testing.T) *HTML {
+	return newHTML(body, exchangeT{T: t})
+}
+
+func newHTML(body []byte, t exchangeT) *HTML {
+	root, err := html.Parse(bytes.NewReader(body))
+	assert.Nil(t, err)
+	return &HTML{
+		body:      body,
+		root:      root,
+		exchangeT: t,
+	}
+}
+
+func (h *HTML) Find(selector string) []*html.Node {
+	sel, err := cascadia.Compile(selector)
+	if !assert.Nil(h.exchangeT, err) || h.root == nil {
+		return nil
+	}
+	return sel.MatchAll(h.root)
+}
+
+func (h *HTML) first(selector string) *html.Node {
+	nodes := h.Find(selector)
+	if !assert.NotEmpty(h.exchangeT, nodes, "no element matches %q", selector) {
+		return nil
+	}
+	return nodes[0]
+}
+
+func (h *HTML) Exist(selector string) *HTML {
+	assert.NotEmpty(h.exchangeT, h.Find(selector), "no element matches %q", selector)
+	return h
+}
+
+func (h *HTML) NotExist(selector string) *HTML {
+	assert.Empty(h.exchangeT, h.Find(selector), "some elements match %q", selector)
+	return h
+}
+
+func (h *HTML) Text(selector, expect string) *HTML {
+	if node := h.first(selector); node != nil {
+		assert.Equal(h.exchangeT, expect, strings.TrimSpace(nodeText(node)))
+	}
+	return h
+}
+
+func (h *HTML) Attr(selector, name, expect string) *HTML {
+	if node := h.first(selector); node != nil {
+		assert.Equal(h.exchangeT, expect, nodeAttr(node, name))
+	}
+	return h
+}
+
+// Click constructs a GET *Request on the same Client to the href of the first element matching selector
+func (h *HTML) Click(selector string) *Request {
+	href := ""
+	if node := h.first(selector); node != nil {
+		assert.True(h.exchangeT, hasAttr(node, "href"), "%q has no href", selector)
+		href = nodeAttr(node, "href")
+	}
+	return h.origin().follow(GET, href, bytes.NewReader([]byte("")))
+}
+
+// origin returns the response the body comes from, NewHTML has none so an empty one is used
+func (h *HTML) origin() *Response {
+	if h.response == nil {
+		return NewResponse(&http.Response{Header: make(http.Header)}, h.T)
+	}
+	return h.response
+}
+
+func (h *HTML) Body() []byte {
+	return h.body
+}
+
+// Form reads action, method and fields (hidden inputs included) of the first form matching selector
+func (h *HTML) Form(selector string) *Form {
+	form := &Form{
+		Method:    GET,
+		Enctype:   MIMEApplicationForm,
+		Fields:    make(url.Values),
+		html:      h,
+		exchangeT: h.exchangeT,
+	}
+	node := h.first(selector)
+	if node == nil {
+		return form
+	}
+	assert.Equal(h.exchangeT, "form", node.Data, "%q does not match a form", selector)
+	form.Action = nodeAttr(node, "action")
+	if method := nodeAttr(node, "method"); method != "" {
+		form.Method = strings.ToUpper(method)
+	}
+	if enctype := nodeAttr(node, "enctype"); enctype != "" {
+		form.Enctype = enctype
+	}
+	walkNodes(node, func(n *html.Node) {
+		if n.Type != html.ElementNode || nodeAttr(n, "name") == "" || hasAttr(n, "disabled") {
+			return
+		}
+		name := nodeAttr(n, "name")
+		switch n.Data {
+		case "input":
+			switch strings.ToLower(nodeAttr(n, "type")) {
+			case "submit", "button", "image", "reset", "file":
+			case "checkbox", "radio":
+				if hasAttr(n, "checked") {
+					value := nodeAttr(n, "value")
+					if !hasAttr(n, "value") {
+						value = "on"
+					}
+					form.Fields.Add(name, value)
+				}
+			default:
+				form.Fields.Add(name, nodeAttr(n, "value"))
+			}
+		case "textarea":
+			form.Fields.Add(name, nodeText(n))
+		case "select":
+			var options, selected []string
+			walkNodes(n, func(option *html.Node) {
+				if option.Type != html.ElementNode || option.Data != "option" {
+					return
+				}
+				value := nodeAttr(option, "value")
+				if !hasAttr(option, "value") {
+					value = strings.TrimSpace(nodeText(option))
+				}
+				options = append(options, value)
+				if hasAttr(option, "selected") {
+					selected = append(selected, value)
+				}
+			})
+			if len(selected) == 0 && len(options) > 0 && !hasAttr(n, "multiple") {
+				selected = options[:1]
+			}
+			for _, value := range selected {
+				form.Fields.Add(name, value)
+			}
+		}
+	})
+	return form
+}
+
+func (f *Form) Set(key, value string) *Form {
+	f.Fields.Set(key, value)
+	return f
+}
+
+func (f *Form) Add(key, value string) *Form {
+	f.Fields.Add(key, value)
+	return f
+}
+
+func (f *Form) Del(key string) *Form {
+	f.Fields.Del(key)
+	return f
+}
+
+func (f *Form) Exist(key string) *Form {
+	_, exist := f.Fields[key]
+	assert.True(f.exchangeT, exist, "form field %q does not exist", key)
+	return f
+}
+
+func (f *Form) NotExist(key string) *Form {
+	_, exist := f.Fields[key]
+	assert.False(f.exchangeT, exist, "form field %q exists", key)
+	return f
+}
+
+func (f *Form) Field(key, expect string) *Form {
+	assert.Equal(f.exchangeT, expect, f.Fields.Get(key))
+	return f
+}
+
+// Submit constructs a *Request on the same Client, keeping cookies of the response the form comes from
+func (f *Form) Submit() *Request {
+	response := f.html.origin()
+	if f.Method == GET {
+		target, err := url.Parse(f.Action)
+		if assert.Nil(f.exchangeT, err) {
+			target.RawQuery = f.Fields.Encode()
+			return response.follow(GET, target.String(), bytes.NewReader([]byte("")))
+		}
+	}
+	if f.Enctype == MIMEMultipartForm {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		for key, values := range f.Fields {
+			for _, value := range values {
+				writer.WriteField(key, value)
+			}
+		}
+		writer.Close()
+		return response.follow(f.Method, f.Action, body).
+			SetHeader(HeaderContentType, writer.FormDataContentType())
+	}
+	return response.follow(f.Method, f.Action, strings.NewReader(f.Fields.Encode())).
+		SetHeader(HeaderContentType, MIMEApplicationForm)
+}
+
+func walkNodes(node *html.Node, fn func(*html.Node)) {
+	fn(node)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		walkNodes(child, fn)
+	}
+}
+
+func nodeText(node *html.Node) string {
+	var buf strings.Builder
+	walkNodes(node, func(n *html.Node) {
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+		}
+	})
+	return buf.String()
+}
+
+func hasAttr(node *html.Node, name string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeAttr(node *html.Node, name string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
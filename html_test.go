@@ -0,0 +1,140 @@
+package htest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	CSRFToken   = "csrf-token-value"
+	SessionName = "session"
+	LoginPage   = `
+<!DOCTYPE html>
+<html>
+<head><title>Login</title></head>
+<body>
+	<h1 class="title">Sign in</h1>
+	<form id="login" action="/html/login" method="post">
+		<input type="hidden" name="csrf" value="csrf-token-value">
+		<input type="text" name="username" value="">
+		<input type="password" name="password">
+		<input type="checkbox" name="remember" checked>
+		<input type="checkbox" name="newsletter" value="yes">
+		<input type="text" name="disabled" value="x" disabled>
+		<select name="lang">
+			<option value="en">English</option>
+			<option value="zh" selected>Chinese</option>
+		</select>
+		<textarea name="bio">hello</textarea>
+		<input type="submit" name="go" value="Sign in">
+	</form>
+	<form id="search" action="/html/search">
+		<input type="text" name="q" value="htest">
+	</form>
+	<a id="home" href="/name">Home</a>
+</body>
+</html>
+`
+)
+
+func TestHTML_Exist(t *testing.T) {
+	NewHTML([]byte(LoginPage), t).
+		Exist("form#login").
+		Exist("input[name=csrf]").
+		NotExist("form#signup")
+}
+
+func TestHTML_Text(t *testing.T) {
+	NewHTML([]byte(LoginPage), t).
+		Text("h1.title", "Sign in").
+		Text("title", "Login")
+}
+
+func TestHTML_Attr(t *testing.T) {
+	NewHTML([]byte(LoginPage), t).
+		Attr("a#home", "href", "/name").
+		Attr("form#login", "method", "post")
+}
+
+func TestHTML_Form(t *testing.T) {
+	form := NewHTML([]byte(LoginPage), t).
+		Form("#login").
+		Field("csrf", CSRFToken).
+		Field("username", "").
+		Field("remember", "on").
+		Field("lang", "zh").
+		Field("bio", "hello").
+		NotExist("newsletter").
+		NotExist("disabled").
+		NotExist("go")
+	assert.Equal(t, POST, form.Method)
+	assert.Equal(t, "/html/login", form.Action)
+}
+
+func TestForm_Submit(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.
+		Get("/html/login").
+		Test().
+		StatusOK().
+		HTML().
+		Form("#login").
+		Set("username", "hexi").
+		Set("password", "secret").
+		Submit().
+		Test().
+		StatusOK().
+		JSON().
+		String("username", "hexi").
+		String("session", "session-value")
+
+	// missing csrf token
+	client.
+		Get("/html/login").
+		Test().
+		StatusOK().
+		HTML().
+		Form("#login").
+		Del("csrf").
+		Submit().
+		Test().
+		StatusForbidden()
+}
+
+func TestForm_Submit_Get(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/html/login").
+		Test().
+		StatusOK().
+		HTML().
+		Form("#search").
+		Submit().
+		Test().
+		StatusOK().
+		JSON().
+		String("q", "htest")
+}
+
+func LoginPageHandler(w http.ResponseWriter, req *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: SessionName, Value: "session-value"})
+	w.Header().Set(HeaderContentType, MIMETextHTMLCharsetUTF8)
+	io.WriteString(w, LoginPage)
+}
+
+func LoginHandler(w http.ResponseWriter, req *http.Request) {
+	session, err := req.Cookie(SessionName)
+	if err != nil || req.PostFormValue("csrf") != CSRFToken {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	io.WriteString(w, fmt.Sprintf(`{"username": "%s", "session": "%s"}`, req.PostFormValue("username"), session.Value))
+}
+
+func SearchHandler(w http.ResponseWriter, req *http.Request) {
+	io.WriteString(w, fmt.Sprintf(`{"q": "%s"}`, req.URL.Query().Get("q")))
+}
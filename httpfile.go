@@ -0,0 +1,266 @@
+package htest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// httpFileRequest is a request of a .http file with the assertions of its comment directives
+	httpFileRequest struct {
+		name    string
+		line    int
+		method  string
+		url     string
+		header  [][2]string
+		body    string
+		status  int
+		headers [][2]string
+		json    []jsonAssertion
+	}
+
+	// jsonAssertion asserts the value at path, op is "==", "exists" or "!exists"
+	jsonAssertion struct {
+		path   string
+		op     string
+		expect string
+	}
+)
+
+var (
+	variablePattern = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+	methodPattern   = regexp.MustCompile(`^(GET|HEAD|POST|PUT|PATCH|DELETE|OPTIONS|TRACE|CONNECT)\s+(\S+)(\s+HTTP/[\d.]+)?$`)
+)
+
+// interpolate replaces {{name}} by vars[name], unknown names are kept
+func interpolate(s string, vars map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if value, exist := vars[variablePattern.FindStringSubmatch(match)[1]]; exist {
+			return value
+		}
+		return match
+	})
+}
+
+// jsonPath accepts JSONPath style "$.data.id" for the gjson path "data.id"
+func jsonPath(path string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+}
+
+// parseJSONAssertion parses "path == value", "path exists" or "path !exists"
+func parseJSONAssertion(s string) (jsonAssertion, error) {
+	if i := strings.Index(s, "=="); i >= 0 {
+		return jsonAssertion{path: jsonPath(strings.TrimSpace(s[:i])), op: "==", expect: strings.TrimSpace(s[i+2:])}, nil
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 2 && (fields[1] == "exists" || fields[1] == "!exists") {
+		return jsonAssertion{path: jsonPath(fields[0]), op: fields[1]}, nil
+	}
+	return jsonAssertion{}, fmt.Errorf("json assertion %q is neither `path == value` nor `path exists`", s)
+}
+
+func (a jsonAssertion) assert(j *JSON) {
+	switch a.op {
+	case "exists":
+		_, exist := j.GetKey(a.path)
+		assert.True(j.exchangeT, exist, "%s does not exist", a.path)
+	case "!exists":
+		_, exist := j.GetKey(a.path)
+		assert.False(j.exchangeT, exist, "%s exists", a.path)
+	default:
+		result, _ := j.GetKey(a.path)
+		assert.Equal(j.exchangeT, a.expect, result.String(), "json %s", a.path)
+	}
+}
+
+func splitHeader(s string) ([2]string, bool) {
+	i := strings.Index(s, ":")
+	if i <= 0 {
+		return [2]string{}, false
+	}
+	return [2]string{strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])}, true
+}
+
+// parseHTTPFile parses requests separated by ###, file variables `@name = value` are interpolated as {{name}}.
+// Comment lines (# or //) before the request line may carry directives, unknown directives are ignored:
+//
+//	# @name get user
+//	# @status 200
+//	# @header Content-Type: application/json
+//	# @json data.id == 1
+//
+// A body line `< ./file` includes the file, relative to dir, as it is and `<@ ./file` with its variables interpolated.
+// Response handlers and redirects after the body are not run.
+func parseHTTPFile(data []byte, dir string) ([]*httpFileRequest, error) {
+	var (
+		requests []*httpFileRequest
+		current  *httpFileRequest
+		pending  = &httpFileRequest{}
+		vars     = make(map[string]string)
+		inBody   bool
+		handled  bool
+		body     []string
+		number   int
+	)
+	flush := func() {
+		if current != nil {
+			current.body = strings.TrimSpace(strings.Join(body, "\n"))
+			requests = append(requests, current)
+		}
+		current, pending, inBody, handled, body = nil, &httpFileRequest{}, false, false, nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		number++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "###") {
+			flush()
+			pending.name = strings.TrimSpace(strings.TrimPrefix(trimmed, "###"))
+			continue
+		}
+		if current != nil {
+			// a response handler `> {% ... %}` or `> script.js`, or a response redirect `<> file` or `>> file`,
+			// ends the request, nothing after it up to ### is sent
+			if strings.HasPrefix(trimmed, ">") || strings.HasPrefix(trimmed, "<>") {
+				handled = true
+			}
+			switch {
+			case handled:
+			case inBody && strings.HasPrefix(trimmed, "<"):
+				name := strings.TrimSpace(strings.TrimPrefix(trimmed[1:], "@"))
+				if !filepath.IsAbs(name) {
+					name = filepath.Join(dir, name)
+				}
+				included, err := ioutil.ReadFile(name)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", number, err)
+				}
+				if strings.HasPrefix(trimmed, "<@") {
+					included = []byte(interpolate(string(included), vars))
+				}
+				body = append(body, string(included))
+			case inBody:
+				body = append(body, interpolate(line, vars))
+			case trimmed == "":
+				inBody = true
+			case strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//"):
+			default:
+				header, ok := splitHeader(interpolate(trimmed, vars))
+				if !ok {
+					return nil, fmt.Errorf("line %d: malformed header %q", number, trimmed)
+				}
+				current.header = append(current.header, header)
+			}
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//"):
+			comment := interpolate(strings.TrimSpace(strings.TrimLeft(trimmed, "#/")), vars)
+			if !strings.HasPrefix(comment, "@") {
+				continue
+			}
+			directive, arg := comment, ""
+			if i := strings.IndexAny(comment, " \t"); i >= 0 {
+				directive, arg = comment[:i], strings.TrimSpace(comment[i+1:])
+			}
+			switch directive {
+			case "@name":
+				pending.name = arg
+			case "@status":
+				status, err := strconv.Atoi(arg)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: status %q is not a number", number, arg)
+				}
+				pending.status = status
+			case "@header":
+				header, ok := splitHeader(arg)
+				if !ok {
+					return nil, fmt.Errorf("line %d: header assertion %q is not `Name: value`", number, arg)
+				}
+				pending.headers = append(pending.headers, header)
+			case "@json":
+				assertion, err := parseJSONAssertion(arg)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", number, err)
+				}
+				pending.json = append(pending.json, assertion)
+			}
+			// other directives such as @no-redirect, @no-cookie-jar or @timeout are for the IDE clients
+		case strings.HasPrefix(trimmed, "@"):
+			variable := strings.SplitN(trimmed[1:], "=", 2)
+			if len(variable) != 2 {
+				return nil, fmt.Errorf("line %d: malformed variable %q", number, trimmed)
+			}
+			vars[strings.TrimSpace(variable[0])] = interpolate(strings.TrimSpace(variable[1]), vars)
+		default:
+			requestLine := interpolate(trimmed, vars)
+			current = pending
+			current.line = number
+			if match := methodPattern.FindStringSubmatch(requestLine); match != nil {
+				current.method, current.url = match[1], match[2]
+			} else if !strings.ContainsAny(requestLine, " \t") {
+				current.method, current.url = GET, requestLine
+			} else {
+				return nil, fmt.Errorf("line %d: malformed request line %q", number, trimmed)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return requests, nil
+}
+
+// RunHTTPFile runs every request of a .http file (JetBrains / VS Code REST Client style) as a subtest,
+// by Request.Test if client has a mock server, otherwise by Request.Send. See parseHTTPFile for directives.
+func RunHTTPFile(t *testing.T, client *Client, path string) {
+	data, err := ioutil.ReadFile(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	requests, err := parseHTTPFile(data, filepath.Dir(path))
+	if !assert.Nil(t, err, "%s is malformed", path) {
+		return
+	}
+	for _, request := range requests {
+		request := request
+		name := request.name
+		if name == "" {
+			name = request.method + " " + request.url
+		}
+		t.Run(name, func(t *testing.T) {
+			c := *client
+			c.T = t
+			req := c.request(request.method, request.url, strings.NewReader(request.body))
+			for _, header := range request.header {
+				req.Header.Add(header[0], header[1])
+			}
+			response := req.do()
+			if request.status != 0 {
+				response.Code(request.status)
+			}
+			for _, header := range request.headers {
+				assert.Equal(response.t(), header[1], response.Header.Get(header[0]), "%s:%d header %s", path, request.line, header[0])
+			}
+			if len(request.json) > 0 {
+				body := newJSON(response.Bytes(), response.t())
+				for _, assertion := range request.json {
+					assertion.assert(body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package htest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHTTPFile(t *testing.T) {
+	RunHTTPFile(t, NewClient(t).To(Mux), "testdata/api.http")
+}
+
+func TestParseHTTPFile(t *testing.T) {
+	requests, err := parseHTTPFile([]byte(`@host = https://api.example.com
+
+### Create
+# @status 201
+# @header Location: {{host}}/users/1
+# @json $.data.id == 1
+POST {{host}}/users HTTP/1.1
+Content-Type: application/json
+# a comment between headers
+
+{"name": "hexi"}
+
+###
+# @no-redirect
+# @timeout 2 m
+// @no-cookie-jar
+GET {{host}}/users?page={{page}}
+
+> {%
+    client.global.set("page", response.body.next);
+%}
+
+###
+POST {{host}}/upload
+Content-Type: text/plain
+
+hello
+<> 2024-01-01T000000.200.json
+>> users.json
+
+### Handler without body
+GET {{host}}/health
+> ./check-health.js
+
+###
+@id = 2
+POST {{host}}/users
+Content-Type: application/json
+
+< ./user.json
+
+###
+POST {{host}}/users
+Content-Type: application/json
+
+<@ user.json
+`), "testdata")
+	assert.Nil(t, err)
+	if assert.Len(t, requests, 6) {
+		create := requests[0]
+		assert.Equal(t, "Create", create.name)
+		assert.Equal(t, 7, create.line)
+		assert.Equal(t, POST, create.method)
+		assert.Equal(t, "https://api.example.com/users", create.url)
+		assert.Equal(t, [][2]string{{"Content-Type", "application/json"}}, create.header)
+		assert.Equal(t, `{"name": "hexi"}`, create.body)
+		assert.Equal(t, 201, create.status)
+		assert.Equal(t, [][2]string{{"Location", "https://api.example.com/users/1"}}, create.headers)
+		assert.Equal(t, []jsonAssertion{{path: "data.id", op: "==", expect: "1"}}, create.json)
+
+		list := requests[1]
+		assert.Equal(t, "", list.name)
+		assert.Equal(t, GET, list.method)
+		assert.Equal(t, "https://api.example.com/users?page={{page}}", list.url)
+		assert.Equal(t, "", list.body)
+
+		upload := requests[2]
+		assert.Equal(t, POST, upload.method)
+		assert.Equal(t, "hello", upload.body)
+
+		health := requests[3]
+		assert.Equal(t, "Handler without body", health.name)
+		assert.Nil(t, health.header)
+		assert.Equal(t, "", health.body)
+
+		assert.Equal(t, `{"id": {{id}}, "name": "hexi"}`, requests[4].body)
+		assert.Equal(t, `{"id": 2, "name": "hexi"}`, requests[5].body)
+	}
+
+	for _, malformed := range []string{
+		"# @status ok\nGET /",
+		"# @json name\nGET /",
+		"GET / HTTP/1.1\nno header",
+		"FETCH / now",
+		"POST /\n\n< ./missing.json",
+	} {
+		_, err := parseHTTPFile([]byte(malformed), "testdata")
+		assert.NotNil(t, err, malformed)
+	}
+}
@@ -0,0 +1,129 @@
+package htest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type (
+	// JSONLines is a sequence of JSON records, one per line (NDJSON / JSON Lines).
+	// At and Each read records only as far as they need; Len and Last read the whole body.
+	// Records read are kept, so that a later pass sees them again, Close a stream not read to the end.
+	JSONLines struct {
+		reader  *bufio.Reader
+		body    io.Closer
+		records [][]byte
+		ended   bool
+		exchangeT
+	}
+)
+
+func NewJSONLines(body io.ReadCloser, t *testing.T) *JSONLines {
+	return newJSONLines(body, exchangeT{T: t})
+}
+
+func newJSONLines(body io.ReadCloser, t exchangeT) *JSONLines {
+	return &JSONLines{
+		reader:    bufio.NewReader(body),
+		body:      body,
+		exchangeT: t,
+	}
+}
+
+func (r *Response) JSONLines() *JSONLines {
+	return newJSONLines(r.Response.Body, r.t())
+}
+
+// next reads the next record from body into records, blank lines are skipped
+func (l *JSONLines) next() bool {
+	for !l.ended {
+		line, err := l.reader.ReadBytes('\n')
+		if err != nil {
+			if err != io.EOF {
+				assert.Nil(l.exchangeT, err)
+			}
+			l.Close()
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		assert.True(l.exchangeT, json.Valid(line), "record %d is not valid json: %s", len(l.records), line)
+		l.records = append(l.records, line)
+		return true
+	}
+	return false
+}
+
+// read reads records until there are n of them or the body ends
+func (l *JSONLines) read(n int) [][]byte {
+	for len(l.records) < n && l.next() {
+	}
+	return l.records
+}
+
+// load reads the rest of the body into records
+func (l *JSONLines) load() [][]byte {
+	for l.next() {
+	}
+	return l.records
+}
+
+// Close stops reading the body, records already read are kept
+func (l *JSONLines) Close() {
+	if !l.ended {
+		l.ended = true
+		l.body.Close()
+	}
+}
+
+func (l *JSONLines) Len(expect int) *JSONLines {
+	assert.Equal(l.exchangeT, expect, len(l.load()))
+	return l
+}
+
+// At returns record i, the body is read only up to it
+func (l *JSONLines) At(i int) *JSON {
+	records := l.read(i + 1)
+	if !assert.True(l.exchangeT, i >= 0 && i < len(records), "no record %d, got %d records", i, len(records)) {
+		return newJSON(nil, l.exchangeT)
+	}
+	return newJSON(records[i], l.exchangeT)
+}
+
+func (l *JSONLines) Last() *JSON {
+	records := l.load()
+	if !assert.NotEqual(l.exchangeT, 0, len(records), "no record") {
+		return newJSON(nil, l.exchangeT)
+	}
+	return newJSON(records[len(records)-1], l.exchangeT)
+}
+
+// Each calls fn on every record until it returns false, the records already read first, then the rest one by one as they arrive
+func (l *JSONLines) Each(fn func(i int, record *JSON) bool) *JSONLines {
+	for i := 0; i < len(l.records) || l.next(); i++ {
+		if !fn(i, newJSON(l.records[i], l.exchangeT)) {
+			break
+		}
+	}
+	return l
+}
+
+// Schema validates every record against a JSON Schema document
+func (l *JSONLines) Schema(schema string) *JSONLines {
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if !assert.Nil(l.exchangeT, err) {
+		return l
+	}
+	return l.Each(func(i int, record *JSON) bool {
+		validateSchema(l.exchangeT, compiled, record.Body(), fmt.Sprintf("record %d: ", i))
+		return true
+	})
+}
@@ -0,0 +1,155 @@
+package htest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	NDJSONDisconnected = make(chan struct{}, 1)
+)
+
+const (
+	NDJSONData = `{"id": 1, "name": "hexi"}
+{"id": 2, "name": "zhwei"}
+
+{"id": 3, "name": "htest"}
+`
+	UserSchema = `{
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer"},
+		"name": {"type": "string"}
+	}
+}`
+)
+
+func TestJSONLines_Len(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/ndjson/users").
+		Test().
+		StatusOK().
+		HeaderContentType(MIMEApplicationNDJSON).
+		JSONLines().
+		Len(3)
+}
+
+func TestJSONLines_At(t *testing.T) {
+	lines := NewClient(t).
+		To(Mux).
+		Get("/ndjson/users").
+		Test().
+		JSONLines()
+	lines.At(0).Int("id", 1)
+	lines.At(2).String("name", "htest")
+	lines.Last().Int("id", 3)
+	lines.Len(3)
+}
+
+func TestJSONLines_Each(t *testing.T) {
+	var ids []int64
+	NewClient(t).
+		To(Mux).
+		Get("/ndjson/users").
+		Stream().
+		StatusOK().
+		JSONLines().
+		Each(func(i int, record *JSON) bool {
+			record.Int("id", int64(i+1))
+			result, _ := record.GetKey("id")
+			ids = append(ids, result.Int())
+			return true
+		}).
+		Len(3).
+		Last().
+		String("name", "htest")
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+}
+
+func TestJSONLines_Schema(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/ndjson/users").
+		Stream().
+		JSONLines().
+		Schema(UserSchema).
+		Len(3)
+}
+
+func TestJSONLines_TwoPasses(t *testing.T) {
+	var first, second []int64
+	lines := NewClient(t).
+		To(Mux).
+		Get("/ndjson/users").
+		Stream().
+		JSONLines()
+	lines.Each(func(i int, record *JSON) bool {
+		result, _ := record.GetKey("id")
+		first = append(first, result.Int())
+		return true
+	}).
+		Schema(UserSchema).
+		Each(func(i int, record *JSON) bool {
+			result, _ := record.GetKey("id")
+			second = append(second, result.Int())
+			return true
+		})
+	assert.Equal(t, []int64{1, 2, 3}, first)
+	assert.Equal(t, first, second)
+
+	var partial []int64
+	lines = NewClient(t).
+		To(Mux).
+		Get("/ndjson/users").
+		Stream().
+		JSONLines()
+	lines.At(0).Int("id", 1)
+	lines.Each(func(i int, record *JSON) bool {
+		result, _ := record.GetKey("id")
+		partial = append(partial, result.Int())
+		return true
+	})
+	assert.Equal(t, []int64{1, 2, 3}, partial)
+}
+
+func TestJSONLines_Endless(t *testing.T) {
+	lines := NewClient(t).
+		To(Mux).
+		Get("/ndjson/forever").
+		Stream().
+		StatusOK().
+		JSONLines()
+	lines.At(1).Int("id", 2)
+	var ids []int64
+	lines.Each(func(i int, record *JSON) bool {
+		result, _ := record.GetKey("id")
+		ids = append(ids, result.Int())
+		return i < 2
+	})
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+	lines.Close()
+	select {
+	case <-NDJSONDisconnected:
+	case <-time.After(time.Second):
+		t.Error("handler is not disconnected")
+	}
+}
+
+func NDJSONHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(HeaderContentType, MIMEApplicationNDJSON)
+	io.WriteString(w, NDJSONData)
+}
+
+func NDJSONForeverHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(HeaderContentType, MIMEApplicationNDJSON)
+	io.WriteString(w, NDJSONData)
+	w.(http.Flusher).Flush()
+	<-req.Context().Done()
+	NDJSONDisconnected <- struct{}{}
+}
@@ -0,0 +1,340 @@
+package htest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// JWT algorithms, chosen by the type of key
+const (
+	JWTHS256 = "HS256"
+	JWTRS256 = "RS256"
+	JWTES256 = "ES256"
+	JWTES384 = "ES384"
+	JWTES512 = "ES512"
+)
+
+type (
+	// JWT is a decoded token, its claims are asserted as JSON
+	JWT struct {
+		*JSON
+		token  string
+		header *JSON
+	}
+)
+
+// jwtAlgorithm returns the algorithm of key: []byte or string for HS256, *rsa.PrivateKey or *rsa.PublicKey for RS256,
+// *ecdsa.PrivateKey or *ecdsa.PublicKey for ES256, ES384 or ES512 by its curve P-256, P-384 or P-521
+func jwtAlgorithm(key interface{}) string {
+	switch k := key.(type) {
+	case []byte, string:
+		return JWTHS256
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return JWTRS256
+	case *ecdsa.PrivateKey:
+		algorithm, _ := ecdsaAlgorithm(k.Curve)
+		return algorithm
+	case *ecdsa.PublicKey:
+		algorithm, _ := ecdsaAlgorithm(k.Curve)
+		return algorithm
+	}
+	return ""
+}
+
+// ecdsaAlgorithm returns the algorithm and its hash for curve, r and s of a signature are as long as the curve order
+func ecdsaAlgorithm(curve elliptic.Curve) (string, crypto.Hash) {
+	switch curve.Params().BitSize {
+	case 256:
+		return JWTES256, crypto.SHA256
+	case 384:
+		return JWTES384, crypto.SHA384
+	case 521:
+		return JWTES512, crypto.SHA512
+	}
+	return "", 0
+}
+
+func ecdsaDigest(curve elliptic.Curve, input string) ([]byte, int) {
+	_, hash := ecdsaAlgorithm(curve)
+	h := hash.New()
+	h.Write([]byte(input))
+	return h.Sum(nil), (curve.Params().BitSize + 7) / 8
+}
+
+func verifyECDSA(key *ecdsa.PublicKey, input string, signature []byte) error {
+	digest, size := ecdsaDigest(key.Curve, input)
+	if len(signature) != 2*size || !ecdsa.Verify(key, digest, new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])) {
+		return fmt.Errorf("jwt signature mismatch")
+	}
+	return nil
+}
+
+func hmacKey(key interface{}) []byte {
+	if s, ok := key.(string); ok {
+		return []byte(s)
+	}
+	return key.([]byte)
+}
+
+// SignJWT mints a compact token of claims signed by key, see jwtAlgorithm for supported keys
+func SignJWT(claims map[string]interface{}, key interface{}) (string, error) {
+	algorithm := jwtAlgorithm(key)
+	if algorithm == "" {
+		return "", fmt.Errorf("unsupported jwt key %T", key)
+	}
+	header, err := json.Marshal(map[string]string{"alg": algorithm, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte, string:
+		mac := hmac.New(sha256.New, hmacKey(k))
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		hashed, size := ecdsaDigest(k.Curve, input)
+		var r, s *big.Int
+		if r, s, err = ecdsa.Sign(rand.Reader, k, hashed); err == nil {
+			signature = make([]byte, 2*size)
+			rb, sb := r.Bytes(), s.Bytes()
+			copy(signature[size-len(rb):size], rb)
+			copy(signature[2*size-len(sb):], sb)
+		}
+	default:
+		return "", fmt.Errorf("jwt cannot be signed by public key %T", key)
+	}
+	if err != nil {
+		return "", err
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verifyJWT checks the signature of input with key, the alg of the token must match the key
+func verifyJWT(algorithm, input string, signature []byte, key interface{}) error {
+	expect := jwtAlgorithm(key)
+	if expect == "" {
+		return fmt.Errorf("unsupported jwt key %T", key)
+	}
+	if algorithm != expect {
+		return fmt.Errorf("jwt is signed by %q, the key is for %q", algorithm, expect)
+	}
+	digest := sha256.Sum256([]byte(input))
+	switch k := key.(type) {
+	case []byte, string:
+		mac := hmac.New(sha256.New, hmacKey(k))
+		mac.Write([]byte(input))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return fmt.Errorf("jwt signature mismatch")
+		}
+		return nil
+	case *rsa.PrivateKey:
+		return rsa.VerifyPKCS1v15(&k.PublicKey, crypto.SHA256, digest[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature)
+	case *ecdsa.PrivateKey:
+		return verifyECDSA(&k.PublicKey, input, signature)
+	case *ecdsa.PublicKey:
+		return verifyECDSA(k, input, signature)
+	}
+	return fmt.Errorf("unsupported jwt key %T", key)
+}
+
+// JWT signs claims with key and sets the token as the Bearer credentials,
+// if expiry is given, iat is now and exp is now + expiry unless claims have them
+func (r *Request) JWT(claims map[string]interface{}, key interface{}, expiry ...time.Duration) *Request {
+	if len(expiry) > 0 {
+		now := time.Now()
+		merged := map[string]interface{}{
+			"iat": now.Unix(),
+			"exp": now.Add(expiry[0]).Unix(),
+		}
+		for name, value := range claims {
+			merged[name] = value
+		}
+		claims = merged
+	}
+	token, err := SignJWT(claims, key)
+	assert.Nil(r.T, err)
+	return r.Bearer(token)
+}
+
+// NewJWT decodes token and verifies its signature with key, a nil key skips the verification
+func NewJWT(token string, key interface{}, t *testing.T) *JWT {
+	return newJWT(token, key, exchangeT{T: t})
+}
+
+func newJWT(token string, key interface{}, t exchangeT) *JWT {
+	jwt := &JWT{
+		JSON:   newJSON(nil, t),
+		token:  token,
+		header: newJSON(nil, t),
+	}
+	parts := strings.Split(token, ".")
+	if !assert.Len(t, parts, 3, "malformed jwt %q", token) {
+		return jwt
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if !assert.Nil(t, err, "malformed jwt header") {
+		return jwt
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if !assert.Nil(t, err, "malformed jwt payload") {
+		return jwt
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if !assert.Nil(t, err, "malformed jwt signature") {
+		return jwt
+	}
+	jwt.header, jwt.JSON = newJSON(header, t), newJSON(payload, t)
+	if key != nil {
+		algorithm := gjson.GetBytes(header, "alg").String()
+		assert.Nil(t, verifyJWT(algorithm, parts[0]+"."+parts[1], signature, key))
+	}
+	return jwt
+}
+
+// JWT decodes the token at path
+func (j *JSON) JWT(path string, key interface{}) *JWT {
+	token, exist := j.GetKey(path)
+	assert.True(j.exchangeT, exist, "no jwt at %q", path)
+	return newJWT(token.String(), key, j.exchangeT)
+}
+
+// JWTFromHeader decodes the token in header name, the Bearer prefix is trimmed
+func (r *Response) JWTFromHeader(name string, key interface{}) *JWT {
+	token := r.Header.Get(name)
+	if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+		token = token[7:]
+	}
+	return newJWT(token, key, r.t())
+}
+
+func (jwt *JWT) Token() string {
+	return jwt.token
+}
+
+// Header asserts a field of the JOSE header, such as alg or kid
+func (jwt *JWT) Header(key, expect string) *JWT {
+	jwt.header.String(key, expect)
+	return jwt
+}
+
+func (jwt *JWT) Subject(expect string) *JWT {
+	jwt.JSON.String("sub", expect)
+	return jwt
+}
+
+func (jwt *JWT) Issuer(expect string) *JWT {
+	jwt.JSON.String("iss", expect)
+	return jwt
+}
+
+// Audience asserts aud is expect or, if it is an array, contains expect
+func (jwt *JWT) Audience(expect string) *JWT {
+	aud, exist := jwt.GetKey("aud")
+	if !assert.True(jwt.exchangeT, exist, "no aud claim") {
+		return jwt
+	}
+	if aud.IsArray() {
+		audiences := make([]string, 0)
+		for _, value := range aud.Array() {
+			audiences = append(audiences, value.String())
+		}
+		assert.Contains(jwt.exchangeT, audiences, expect)
+		return jwt
+	}
+	assert.Equal(jwt.exchangeT, expect, aud.String())
+	return jwt
+}
+
+func (jwt *JWT) claimTime(name string) (time.Time, bool) {
+	value, exist := jwt.GetKey(name)
+	if !assert.True(jwt.exchangeT, exist, "no %s claim", name) {
+		return time.Time{}, false
+	}
+	return time.Unix(value.Int(), 0), true
+}
+
+// ExpiresIn asserts exp is between min and max from now
+func (jwt *JWT) ExpiresIn(min, max time.Duration) *JWT {
+	if exp, ok := jwt.claimTime("exp"); ok {
+		left := time.Until(exp)
+		assert.True(jwt.exchangeT, left >= min-time.Second && left <= max+time.Second, "jwt expires in %s, expect between %s and %s", left, min, max)
+	}
+	return jwt
+}
+
+// NotExpired asserts exp is in the future and nbf, if any, is in the past
+func (jwt *JWT) NotExpired() *JWT {
+	if exp, ok := jwt.claimTime("exp"); ok {
+		assert.True(jwt.exchangeT, time.Now().Before(exp), "jwt expired at %s", exp)
+	}
+	if _, exist := jwt.GetKey("nbf"); exist {
+		nbf, _ := jwt.claimTime("nbf")
+		assert.False(jwt.exchangeT, time.Now().Before(nbf), "jwt is not valid before %s", nbf)
+	}
+	return jwt
+}
+
+func (jwt *JWT) Expired() *JWT {
+	if exp, ok := jwt.claimTime("exp"); ok {
+		assert.False(jwt.exchangeT, time.Now().Before(exp), "jwt expires at %s", exp)
+	}
+	return jwt
+}
+
+func (jwt *JWT) Exist(key string) *JWT {
+	jwt.JSON.Exist(key)
+	return jwt
+}
+
+func (jwt *JWT) NotExist(key string) *JWT {
+	jwt.JSON.NotExist(key)
+	return jwt
+}
+
+func (jwt *JWT) String(key, expect string) *JWT {
+	jwt.JSON.String(key, expect)
+	return jwt
+}
+
+func (jwt *JWT) Int(key string, expect int64) *JWT {
+	jwt.JSON.Int(key, expect)
+	return jwt
+}
+
+func (jwt *JWT) True(key string) *JWT {
+	jwt.JSON.True(key)
+	return jwt
+}
+
+func (jwt *JWT) False(key string) *JWT {
+	jwt.JSON.False(key)
+	return jwt
+}
@@ -0,0 +1,143 @@
+package htest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	JWTSecret = "jwt-secret"
+)
+
+func TestRequest_JWT(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.Get("/jwt/me").
+		JWT(map[string]interface{}{"sub": "hexi", "role": "admin"}, JWTSecret, time.Minute).
+		Test().
+		StatusOK().
+		JSON().
+		String("sub", "hexi").
+		String("role", "admin")
+
+	client.Get("/jwt/me").
+		JWT(map[string]interface{}{"sub": "hexi"}, JWTSecret, -time.Minute).
+		Test().
+		StatusUnauthorized()
+
+	client.Get("/jwt/me").
+		JWT(map[string]interface{}{"sub": "hexi"}, "wrong-secret", time.Minute).
+		Test().
+		StatusUnauthorized()
+}
+
+func TestJSON_JWT(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.Post("/jwt/token", nil).Test().
+		StatusOK().
+		JSON().
+		JWT("access_token", JWTSecret).
+		Header("alg", JWTHS256).
+		Subject("hexi").
+		Issuer("htest").
+		Audience("api").
+		ExpiresIn(50*time.Minute, time.Hour).
+		NotExpired().
+		String("scope", "read write").
+		True("admin")
+}
+
+func TestResponse_JWTFromHeader(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.Post("/jwt/token", nil).Test().
+		StatusOK().
+		JWTFromHeader(HeaderAuthorization, []byte(JWTSecret)).
+		Subject("hexi").
+		Audience("web").
+		Int("level", 3)
+}
+
+func TestSignJWT(t *testing.T) {
+	claims := map[string]interface{}{"sub": "hexi", "exp": time.Now().Add(-time.Hour).Unix()}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	token, err := SignJWT(claims, rsaKey)
+	assert.Nil(t, err)
+	NewJWT(token, &rsaKey.PublicKey, t).Header("alg", JWTRS256).Subject("hexi").Expired()
+
+	for curve, algorithm := range map[elliptic.Curve]string{
+		elliptic.P256(): JWTES256,
+		elliptic.P384(): JWTES384,
+		elliptic.P521(): JWTES512,
+	} {
+		ecKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		assert.Nil(t, err)
+		token, err = SignJWT(claims, ecKey)
+		assert.Nil(t, err, algorithm)
+		NewJWT(token, &ecKey.PublicKey, t).Header("alg", algorithm).Subject("hexi")
+		NewJWT(token, ecKey, t).Header("alg", algorithm)
+		assert.NotNil(t, verifyJWT(algorithm, "tampered", []byte(strings.Repeat("x", 64)), &ecKey.PublicKey))
+	}
+	assert.NotNil(t, verifyJWT(JWTES256, "", nil, JWTSecret), "alg must match the key")
+	assert.NotNil(t, verifyJWT("", "", nil, 42), "unsupported key")
+
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	assert.Nil(t, err)
+	_, err = SignJWT(claims, p224)
+	assert.NotNil(t, err)
+	assert.NotNil(t, verifyJWT("", "", nil, &p224.PublicKey), "unsupported curve")
+
+	_, err = SignJWT(claims, &rsaKey.PublicKey)
+	assert.NotNil(t, err)
+}
+
+func hs256(claims map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims)
+	input := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(JWTSecret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func JWTTokenHandler(w http.ResponseWriter, req *http.Request) {
+	exp := time.Now().Add(time.Hour).Unix()
+	w.Header().Set(HeaderAuthorization, "Bearer "+hs256(map[string]interface{}{"sub": "hexi", "aud": "web", "level": 3, "exp": exp}))
+	fmt.Fprintf(w, `{"access_token": %q, "token_type": "Bearer"}`,
+		hs256(map[string]interface{}{"sub": "hexi", "iss": "htest", "aud": []string{"api", "web"}, "exp": exp, "scope": "read write", "admin": true}))
+}
+
+// JWTMeHandler verifies the HS256 bearer token and echoes its claims
+func JWTMeHandler(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(req.Header.Get(HeaderAuthorization), "Bearer "), ".")
+	if len(parts) != 3 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(JWTSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	json.Unmarshal(payload, &claims)
+	if !hmac.Equal(signature, mac.Sum(nil)) || claims.Exp < time.Now().Unix() {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	w.Write(payload)
+}
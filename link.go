@@ -0,0 +1,82 @@
+package htest
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// FollowLink constructs a GET *Request on the same Client to the link with relation rel.
+// Links are looked up in the Link header, then in HAL "_links" and JSON:API "links" of the body.
+func (r *Response) FollowLink(rel string) *Request {
+	href, exist := r.link(rel)
+	assert.True(r.t(), exist, "no link with relation %q", rel)
+	return r.follow(GET, href, bytes.NewReader([]byte("")))
+}
+
+func (r *Response) link(rel string) (href string, exist bool) {
+	if href, exist = parseLinkHeader(r.Header[HeaderLink])[rel]; exist {
+		return
+	}
+	body := r.peek()
+	key := strings.Replace(rel, ".", `\.`, -1)
+	for _, path := range []string{"_links." + key + ".href", "links." + key + ".href", "links." + key} {
+		if result := gjson.GetBytes(body, path); result.Type == gjson.String {
+			return result.String(), true
+		}
+	}
+	return "", false
+}
+
+// parseLinkHeader maps each relation type in Link header values (RFC 8288) to its target
+func parseLinkHeader(values []string) map[string]string {
+	links := make(map[string]string)
+	for _, value := range values {
+		for _, link := range splitQuoted(value, ',') {
+			params := splitQuoted(link, ';')
+			target := strings.TrimSpace(params[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = target[1 : len(target)-1]
+			for _, param := range params[1:] {
+				pair := strings.SplitN(param, "=", 2)
+				if len(pair) != 2 || !strings.EqualFold(strings.TrimSpace(pair[0]), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(pair[1]), `"`)) {
+					if _, exist := links[rel]; !exist {
+						links[rel] = target
+					}
+				}
+			}
+		}
+	}
+	return links
+}
+
+// splitQuoted splits s by sep, ignoring separators inside quotes or angle brackets
+func splitQuoted(s string, sep rune) []string {
+	var (
+		parts     []string
+		quoted    bool
+		bracketed bool
+		start     int
+	)
+	for i, c := range s {
+		switch {
+		case c == '"' && !bracketed:
+			quoted = !quoted
+		case c == '<' && !quoted:
+			bracketed = true
+		case c == '>' && !quoted:
+			bracketed = false
+		case c == sep && !quoted && !bracketed:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,89 @@
+package htest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLinkHeader(t *testing.T) {
+	links := parseLinkHeader([]string{
+		`<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=5>; rel="last"`,
+		`</items?a=1,2>; title="x;y"; rel="prev first"`,
+	})
+	assert.Equal(t, "https://api.example.com/items?page=2", links["next"])
+	assert.Equal(t, "https://api.example.com/items?page=5", links["last"])
+	assert.Equal(t, "/items?a=1,2", links["prev"])
+	assert.Equal(t, "/items?a=1,2", links["first"])
+}
+
+func TestResponse_FollowLink(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.
+		Get("/link/header").
+		Test().
+		StatusOK().
+		FollowLink("next").
+		Test().
+		StatusOK().
+		JSON().
+		String("name", "hexi")
+
+	client.
+		Get("/link/hal").
+		Test().
+		StatusOK().
+		FollowLink("author").
+		Test().
+		StatusOK().
+		JSON().
+		String("name", "hexi")
+
+	client.
+		Get("/link/jsonapi").
+		Test().
+		StatusOK().
+		FollowLink("related").
+		Test().
+		StatusOK().
+		JSON().
+		String("name", "hexi")
+}
+
+func TestResponse_FollowLink_Keep_Body(t *testing.T) {
+	response := NewClient(t).
+		To(Mux).
+		Get("/link/hal").
+		Test()
+	response.FollowLink("self")
+	response.JSON().String("_links.self.href", "/link/hal")
+}
+
+func TestHTML_Click(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/html/login").
+		Test().
+		StatusOK().
+		HTML().
+		Click("a#home").
+		Test().
+		StatusOK().
+		JSON().
+		String("name", "hexi")
+}
+
+func LinkHeaderHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(HeaderLink, `</name>; rel="next"`)
+	io.WriteString(w, `{}`)
+}
+
+func HALHandler(w http.ResponseWriter, req *http.Request) {
+	io.WriteString(w, `{"_links": {"self": {"href": "/link/hal"}, "author": {"href": "/name"}}}`)
+}
+
+func JSONAPIHandler(w http.ResponseWriter, req *http.Request) {
+	io.WriteString(w, `{"data": [], "links": {"self": "/link/jsonapi", "related": {"href": "../name"}}}`)
+}
@@ -0,0 +1,154 @@
+package htest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type (
+	// Msgpack converts a MessagePack body to JSON for assertions, Bind decodes the original body.
+	// Struct fields are named by their json tags, as encoding/json does.
+	Msgpack struct {
+		*JSON
+		body []byte
+	}
+)
+
+func NewMsgpack(body []byte, t *testing.T) *Msgpack {
+	return newMsgpack(body, exchangeT{T: t})
+}
+
+func newMsgpack(body []byte, t exchangeT) *Msgpack {
+	var jsonBody []byte
+	if len(body) > 0 {
+		var value interface{}
+		decoder := msgpack.NewDecoder(bytes.NewReader(body))
+		decoder.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+			return d.DecodeUntypedMap()
+		})
+		err := decoder.Decode(&value)
+		assert.Nil(t, err)
+		jsonBody, err = json.Marshal(jsonCompatible(value))
+		assert.Nil(t, err)
+	}
+	return &Msgpack{
+		body: body,
+		JSON: newJSON(jsonBody, t),
+	}
+}
+
+func (r *Response) Msgpack() *Msgpack {
+	body, err := ioutil.ReadAll(r.Response.Body)
+	r.Response.Body.Close()
+	assert.Nil(r.t(), err)
+	return newMsgpack(body, r.t())
+}
+
+// MsgpackBody encodes v as the MessagePack body of the request
+func (r *Request) MsgpackBody(v interface{}) *Request {
+	buf := new(bytes.Buffer)
+	encoder := msgpack.NewEncoder(buf)
+	encoder.SetCustomStructTag("json")
+	assert.Nil(r.T, encoder.Encode(v))
+	return r.setBody(buf.Bytes(), MIMEApplicationMsgpack)
+}
+
+// jsonCompatible converts maps with non-string keys, which encoding/json rejects, to map[string]interface{}
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(elem)
+		}
+		return m
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = jsonCompatible(elem)
+		}
+		return v
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = jsonCompatible(elem)
+		}
+		return v
+	}
+	return value
+}
+
+func (m *Msgpack) Exist(key string) *Msgpack {
+	m.JSON.Exist(key)
+	return m
+}
+
+func (m *Msgpack) NotExist(key string) *Msgpack {
+	m.JSON.NotExist(key)
+	return m
+}
+
+func (m *Msgpack) String(key, expect string) *Msgpack {
+	m.JSON.String(key, expect)
+	return m
+}
+
+func (m *Msgpack) Int(key string, expect int64) *Msgpack {
+	m.JSON.Int(key, expect)
+	return m
+}
+
+func (m *Msgpack) True(key string) *Msgpack {
+	m.JSON.True(key)
+	return m
+}
+
+func (m *Msgpack) False(key string) *Msgpack {
+	m.JSON.False(key)
+	return m
+}
+
+func (m *Msgpack) Uint(key string, expect uint64) *Msgpack {
+	m.JSON.Uint(key, expect)
+	return m
+}
+
+func (m *Msgpack) Time(key string, expect time.Time) *Msgpack {
+	m.JSON.Time(key, expect)
+	return m
+}
+
+func (m *Msgpack) Float(key string, expect float64) *Msgpack {
+	m.JSON.Float(key, expect)
+	return m
+}
+
+func (m *Msgpack) Schema(schema string) *Msgpack {
+	m.JSON.Schema(schema)
+	return m
+}
+
+func (m *Msgpack) Empty() *Msgpack {
+	assert.Equal(m.exchangeT, "", string(m.Body()))
+	return m
+}
+
+func (m *Msgpack) NotEmpty() *Msgpack {
+	assert.NotEqual(m.exchangeT, "", string(m.Body()))
+	return m
+}
+
+func (m *Msgpack) Body() []byte {
+	return m.body
+}
+
+func (m *Msgpack) Bind(obj interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(m.body))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(obj)
+}
@@ -0,0 +1,74 @@
+package htest
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpack_Assert(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Post("/msgpack/echo", nil).
+		MsgpackBody(AssertStruct{Number: 1, Time: AssertDataTime, OK: true}).
+		Test().
+		StatusOK().
+		HeaderContentType(MIMEApplicationMsgpack).
+		Msgpack().
+		Exist("time").
+		NotExist("Number").
+		Int("number", 1).
+		Uint("number", 1).
+		Float("number", 1).
+		Time("time", AssertDataTime).
+		True("ok").
+		False("no").
+		NotEmpty()
+}
+
+func TestMsgpack_Bind(t *testing.T) {
+	data := new(AssertStruct)
+	err := NewClient(t).
+		To(Mux).
+		Post("/msgpack/echo", nil).
+		MsgpackBody(AssertStruct{Number: 1, Time: AssertDataTime, OK: true}).
+		Test().
+		StatusOK().
+		Msgpack().
+		Bind(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, data.Number)
+	assert.True(t, AssertDataTime.Equal(data.Time))
+	assert.True(t, data.OK)
+}
+
+func TestMsgpack_NonStringKeys(t *testing.T) {
+	body, err := msgpack.Marshal(map[int]string{1: "hexi"})
+	assert.Nil(t, err)
+	NewMsgpack(body, t).
+		String("1", "hexi")
+}
+
+func TestMsgpack_Empty(t *testing.T) {
+	NewMsgpack(nil, t).
+		Empty()
+}
+
+func MsgpackEchoHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get(HeaderContentType) != MIMEApplicationMsgpack {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	var value interface{}
+	if err := msgpack.NewDecoder(req.Body).Decode(&value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	buf := new(bytes.Buffer)
+	msgpack.NewEncoder(buf).Encode(value)
+	w.Header().Set(HeaderContentType, MIMEApplicationMsgpack)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,413 @@
+package htest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// OAuth2 grant types
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+)
+
+const (
+	DefaultOAuthTokenTTL = time.Hour
+)
+
+type (
+	// OAuthGrant is a token issued by OAuthServer, User is empty for client credentials
+	OAuthGrant struct {
+		Type     string
+		ClientID string
+		User     string
+		Scope    string
+	}
+
+	oauthClient struct {
+		secret      string
+		redirectURI string
+		scopes      []string
+	}
+
+	oauthCode struct {
+		clientID    string
+		user        string
+		redirectURI string
+		scope       string
+		challenge   string
+		method      string
+	}
+
+	// OAuthServer is a fake OAuth2 authorization server serving /authorize, /token and /userinfo.
+	// Access tokens are HS256 JWTs signed by Key, users approve every request, the user is chosen by login_hint.
+	OAuthServer struct {
+		mu        sync.Mutex
+		users     map[string][]string
+		clients   map[string]*oauthClient
+		codes     map[string]*oauthCode
+		refreshes map[string]OAuthGrant
+		grants    []OAuthGrant
+		key       []byte
+		ttl       time.Duration
+		server    *httptest.Server
+	}
+)
+
+func NewOAuthServer() *OAuthServer {
+	return &OAuthServer{
+		users:     make(map[string][]string),
+		clients:   make(map[string]*oauthClient),
+		codes:     make(map[string]*oauthCode),
+		refreshes: make(map[string]OAuthGrant),
+		key:       []byte(randomToken()),
+		ttl:       DefaultOAuthTokenTTL,
+	}
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// User registers a user who may grant scopes, no scope means any scope
+func (s *OAuthServer) User(name string, scopes ...string) *OAuthServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[name] = scopes
+	return s
+}
+
+// Client registers a client, an empty secret makes it a public client which must use PKCE
+func (s *OAuthServer) Client(id, secret, redirectURI string, scopes ...string) *OAuthServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[id] = &oauthClient{secret: secret, redirectURI: redirectURI, scopes: scopes}
+	return s
+}
+
+func (s *OAuthServer) TokenTTL(ttl time.Duration) *OAuthServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttl = ttl
+	return s
+}
+
+// Key returns the HS256 key of access tokens, for JSON.JWT and Response.JWTFromHeader
+func (s *OAuthServer) Key() []byte {
+	return s.key
+}
+
+// Start serves s over a local listener and returns its url, the handler under test can reach it as a real server
+func (s *OAuthServer) Start() string {
+	if s.server == nil {
+		s.server = httptest.NewServer(s)
+	}
+	return s.server.URL
+}
+
+func (s *OAuthServer) URL() string {
+	if s.server == nil {
+		return ""
+	}
+	return s.server.URL
+}
+
+func (s *OAuthServer) Close() {
+	if s.server != nil {
+		s.server.Close()
+		s.server = nil
+	}
+}
+
+// Grants returns the issued tokens in order
+func (s *OAuthServer) Grants() []OAuthGrant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]OAuthGrant(nil), s.grants...)
+}
+
+func (s *OAuthServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/authorize"):
+		s.authorize(w, req)
+	case strings.HasSuffix(req.URL.Path, "/token") && req.Method == POST:
+		s.token(w, req)
+	case strings.HasSuffix(req.URL.Path, "/userinfo"):
+		s.userinfo(w, req)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func oauthError(w http.ResponseWriter, code int, err, description string) {
+	w.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	w.Header().Set(HeaderCacheControl, "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err, "error_description": description})
+}
+
+// grantable returns the granted scope, requested must be allowed by every list of allowed
+func grantable(requested string, allowed ...[]string) (string, bool) {
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		for _, list := range allowed {
+			if len(list) > 0 {
+				scopes = list
+				break
+			}
+		}
+	}
+	for _, scope := range scopes {
+		for _, list := range allowed {
+			if len(list) > 0 && !containsString(list, scope) {
+				return "", false
+			}
+		}
+	}
+	return strings.Join(scopes, " "), true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *OAuthServer) authorize(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, exist := s.clients[query.Get("client_id")]
+	if !exist {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "unknown client_id")
+		return
+	}
+	redirectURI := query.Get("redirect_uri")
+	if redirectURI == "" {
+		redirectURI = client.redirectURI
+	}
+	location, err := url.Parse(redirectURI)
+	if redirectURI != client.redirectURI || err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "redirect_uri is not registered")
+		return
+	}
+	redirect := func(params url.Values) {
+		if state := query.Get("state"); state != "" {
+			params.Set("state", state)
+		}
+		location.RawQuery = params.Encode()
+		http.Redirect(w, req, location.String(), http.StatusFound)
+	}
+	method := query.Get("code_challenge_method")
+	if query.Get("code_challenge") != "" && method == "" {
+		method = "plain"
+	}
+	user, known := s.users[query.Get("login_hint")]
+	scope, allowed := grantable(query.Get("scope"), client.scopes, user)
+	switch {
+	case query.Get("response_type") != "code":
+		redirect(url.Values{"error": {"unsupported_response_type"}})
+	case client.secret == "" && query.Get("code_challenge") == "":
+		redirect(url.Values{"error": {"invalid_request"}, "error_description": {"public client must use PKCE"}})
+	case method != "" && method != "S256" && method != "plain":
+		redirect(url.Values{"error": {"invalid_request"}, "error_description": {"unsupported code_challenge_method"}})
+	case !known:
+		redirect(url.Values{"error": {"login_required"}})
+	case !allowed:
+		redirect(url.Values{"error": {"invalid_scope"}})
+	default:
+		code := randomToken()
+		s.codes[code] = &oauthCode{
+			clientID:    query.Get("client_id"),
+			user:        query.Get("login_hint"),
+			redirectURI: query.Get("redirect_uri"),
+			scope:       scope,
+			challenge:   query.Get("code_challenge"),
+			method:      method,
+		}
+		redirect(url.Values{"code": {code}})
+	}
+}
+
+// authenticate returns the client by HTTP Basic or client_id/client_secret, a public client has no secret to check
+func (s *OAuthServer) authenticate(req *http.Request) (string, *oauthClient, bool) {
+	id, secret, basic := req.BasicAuth()
+	if !basic {
+		id, secret = req.PostForm.Get("client_id"), req.PostForm.Get("client_secret")
+	}
+	client, exist := s.clients[id]
+	if !exist || subtle.ConstantTimeCompare([]byte(client.secret), []byte(secret)) != 1 {
+		return id, nil, false
+	}
+	return id, client, true
+}
+
+func (s *OAuthServer) token(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clientID, client, ok := s.authenticate(req)
+	if !ok {
+		oauthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	form := req.PostForm
+	grant := OAuthGrant{Type: form.Get("grant_type"), ClientID: clientID}
+	switch grant.Type {
+	case GrantAuthorizationCode:
+		code, exist := s.codes[form.Get("code")]
+		delete(s.codes, form.Get("code"))
+		if !exist || code.clientID != clientID || code.redirectURI != form.Get("redirect_uri") {
+			oauthError(w, http.StatusBadRequest, "invalid_grant", "code is invalid, used or issued to another client")
+			return
+		}
+		if !verifyPKCE(code.challenge, code.method, form.Get("code_verifier")) {
+			oauthError(w, http.StatusBadRequest, "invalid_grant", "code_verifier does not match code_challenge")
+			return
+		}
+		grant.User, grant.Scope = code.user, code.scope
+	case GrantClientCredentials:
+		scope, allowed := grantable(form.Get("scope"), client.scopes)
+		if client.secret == "" || !allowed {
+			oauthError(w, http.StatusBadRequest, "unauthorized_client", "client credentials need a confidential client and allowed scope")
+			return
+		}
+		grant.Scope = scope
+	case GrantRefreshToken:
+		previous, exist := s.refreshes[form.Get("refresh_token")]
+		if !exist || previous.ClientID != clientID {
+			oauthError(w, http.StatusBadRequest, "invalid_grant", "refresh_token is invalid or issued to another client")
+			return
+		}
+		scope, allowed := grantable(form.Get("scope"), strings.Fields(previous.Scope))
+		if !allowed {
+			oauthError(w, http.StatusBadRequest, "invalid_scope", "scope exceeds the original grant")
+			return
+		}
+		delete(s.refreshes, form.Get("refresh_token"))
+		grant.User, grant.Scope = previous.User, scope
+	default:
+		oauthError(w, http.StatusBadRequest, "unsupported_grant_type", grant.Type)
+		return
+	}
+	s.issue(w, req, grant)
+}
+
+func verifyPKCE(challenge, method, verifier string) bool {
+	switch method {
+	case "":
+		return true
+	case "plain":
+		return verifier != "" && verifier == challenge
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier != "" && base64.RawURLEncoding.EncodeToString(sum[:]) == challenge
+}
+
+func (s *OAuthServer) issue(w http.ResponseWriter, req *http.Request, grant OAuthGrant) {
+	now := time.Now()
+	subject := grant.User
+	if subject == "" {
+		subject = grant.ClientID
+	}
+	access, err := SignJWT(map[string]interface{}{
+		"iss":       "http://" + req.Host,
+		"sub":       subject,
+		"aud":       grant.ClientID,
+		"client_id": grant.ClientID,
+		"scope":     grant.Scope,
+		"iat":       now.Unix(),
+		"exp":       now.Add(s.ttl).Unix(),
+	}, s.key)
+	if err != nil {
+		oauthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	body := map[string]interface{}{
+		"access_token": access,
+		"token_type":   "Bearer",
+		"expires_in":   int(s.ttl / time.Second),
+		"scope":        grant.Scope,
+	}
+	if grant.Type != GrantClientCredentials {
+		refresh := randomToken()
+		s.refreshes[refresh] = grant
+		body["refresh_token"] = refresh
+	}
+	s.grants = append(s.grants, grant)
+	w.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	w.Header().Set(HeaderCacheControl, "no-store")
+	json.NewEncoder(w).Encode(body)
+}
+
+func (s *OAuthServer) userinfo(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(req.Header.Get(HeaderAuthorization), "Bearer "), ".")
+	if len(parts) == 3 {
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		if verifyJWT(JWTHS256, parts[0]+"."+parts[1], signature, s.key) == nil && gjson.GetBytes(claims, "exp").Int() > time.Now().Unix() {
+			w.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+			json.NewEncoder(w).Encode(map[string]string{
+				"sub":   gjson.GetBytes(claims, "sub").String(),
+				"scope": gjson.GetBytes(claims, "scope").String(),
+			})
+			return
+		}
+	}
+	w.Header().Set(HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+	oauthError(w, http.StatusUnauthorized, "invalid_token", "access token is invalid or expired")
+}
+
+// OAuthLogin runs the login dance as user: path of the handler redirects to server,
+// server approves, and the callback of the handler is requested with the code and the cookies set on the way.
+// A refused authorization fails with the error of server and returns its redirect
+func (c Client) OAuthLogin(path string, server *OAuthServer, user string) *Response {
+	start := c.Get(path).do()
+	location := start.Header.Get(HeaderLocation)
+	if !assert.True(c.T, start.StatusCode >= 300 && start.StatusCode < 400 && location != "", "%s does not redirect to the authorization server", path) {
+		return start
+	}
+	authorize, err := url.Parse(location)
+	if !assert.Nil(c.T, err) {
+		return start
+	}
+	query := authorize.Query()
+	query.Set("login_hint", user)
+	authorize.RawQuery = query.Encode()
+	approval := NewClient(c.T).To(server).Get(authorize.String()).Test()
+	callback := approval.Header.Get(HeaderLocation)
+	if !assert.Equal(c.T, http.StatusFound, approval.StatusCode, "authorization is refused") {
+		return approval
+	}
+	redirect, err := url.Parse(callback)
+	if !assert.Nil(c.T, err) {
+		return approval
+	}
+	if refusal := redirect.Query().Get("error"); refusal != "" {
+		assert.Fail(c.T, "authorization is refused", "%s: %s %s", user, refusal, redirect.Query().Get("error_description"))
+		return approval
+	}
+	return start.follow(GET, callback, nil).do()
+}
@@ -0,0 +1,201 @@
+package htest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	OAuthCallback = "http://app.test/oauth/callback"
+	OAuthVerifier = "dBjftJeZ4CVP-mJ92K9TfYnWyCy2K1oAxN4VbB2Cmq0"
+)
+
+func newOAuthServer() *OAuthServer {
+	return NewOAuthServer().
+		User("hexi", "profile", "email").
+		User("guest", "profile").
+		Client("app", "", OAuthCallback, "profile", "email").
+		Client("worker", "worker-secret", "", "jobs:read", "jobs:write")
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// OAuthAppHandler is an OAuth2 client app which logs users in with the authorization server at issuer
+func OAuthAppHandler(issuer string) http.Handler {
+	mux := chi.NewRouter()
+	mux.Get("/oauth/login", func(w http.ResponseWriter, req *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "oauth_state", Value: "xyz", Path: "/"})
+		http.Redirect(w, req, issuer+"/authorize?"+url.Values{
+			"response_type":         {"code"},
+			"client_id":             {"app"},
+			"redirect_uri":          {OAuthCallback},
+			"scope":                 {req.URL.Query().Get("scope")},
+			"state":                 {"xyz"},
+			"code_challenge":        {pkceChallenge(OAuthVerifier)},
+			"code_challenge_method": {"S256"},
+		}.Encode(), http.StatusFound)
+	})
+	mux.Get("/oauth/callback", func(w http.ResponseWriter, req *http.Request) {
+		state, err := req.Cookie("oauth_state")
+		if err != nil || state.Value != req.URL.Query().Get("state") {
+			http.Error(w, "state mismatch", http.StatusForbidden)
+			return
+		}
+		if oauthErr := req.URL.Query().Get("error"); oauthErr != "" {
+			http.Error(w, oauthErr, http.StatusUnauthorized)
+			return
+		}
+		resp, err := http.PostForm(issuer+"/token", url.Values{
+			"grant_type":    {GrantAuthorizationCode},
+			"code":          {req.URL.Query().Get("code")},
+			"redirect_uri":  {OAuthCallback},
+			"client_id":     {"app"},
+			"code_verifier": {OAuthVerifier},
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	})
+	return mux
+}
+
+func TestClient_OAuthLogin(t *testing.T) {
+	server := newOAuthServer()
+	defer server.Close()
+	client := NewClient(t).To(OAuthAppHandler(server.Start()))
+
+	client.OAuthLogin("/oauth/login?scope=profile+email", server, "hexi").
+		StatusOK().
+		JSON().
+		String("token_type", "Bearer").
+		String("scope", "profile email").
+		Exist("refresh_token").
+		JWT("access_token", server.Key()).
+		Issuer(server.URL()).
+		Subject("hexi").
+		Audience("app").
+		ExpiresIn(time.Hour, time.Hour)
+
+	assert.Equal(t, []OAuthGrant{{Type: GrantAuthorizationCode, ClientID: "app", User: "hexi", Scope: "profile email"}}, server.Grants())
+}
+
+func TestClient_OAuthLogin_Refused(t *testing.T) {
+	output := expectFailure(t, "TestClient_OAuthLogin_RefusedFailing")
+	assert.Contains(t, output, "guest: invalid_scope")
+	assert.Contains(t, output, "nobody: login_required")
+}
+
+func TestClient_OAuthLogin_RefusedFailing(t *testing.T) {
+	failing(t)
+	server := newOAuthServer()
+	defer server.Close()
+	client := NewClient(t).To(OAuthAppHandler(server.Start()))
+	client.OAuthLogin("/oauth/login?scope=email", server, "guest").StatusFound()
+	client.OAuthLogin("/oauth/login", server, "nobody").StatusFound()
+	assert.Empty(t, server.Grants())
+}
+
+func tokenRequest(client *Client, form url.Values) *Request {
+	return client.Post("/token", strings.NewReader(form.Encode())).SetHeader(HeaderContentType, MIMEApplicationForm)
+}
+
+func TestOAuthServer_ClientCredentials(t *testing.T) {
+	server := newOAuthServer()
+	client := NewClient(t).To(server)
+
+	tokenRequest(client, url.Values{"grant_type": {GrantClientCredentials}, "scope": {"jobs:read"}}).
+		BasicAuth("worker", "worker-secret").
+		Test().
+		StatusOK().
+		HeaderCacheControl("no-store").
+		JSON().
+		NotExist("refresh_token").
+		JWT("access_token", server.Key()).
+		Subject("worker").
+		String("scope", "jobs:read")
+
+	tokenRequest(client, url.Values{"grant_type": {GrantClientCredentials}}).
+		BasicAuth("worker", "wrong").
+		Test().
+		StatusUnauthorized().
+		JSON().
+		String("error", "invalid_client")
+	tokenRequest(client, url.Values{"grant_type": {GrantClientCredentials}, "scope": {"admin"}, "client_id": {"worker"}, "client_secret": {"worker-secret"}}).
+		Test().
+		StatusBadRequest()
+	tokenRequest(client, url.Values{"grant_type": {GrantClientCredentials}, "client_id": {"app"}}).
+		Test().
+		StatusBadRequest().
+		JSON().
+		String("error", "unauthorized_client")
+
+	assert.Equal(t, []OAuthGrant{{Type: GrantClientCredentials, ClientID: "worker", Scope: "jobs:read"}}, server.Grants())
+}
+
+func TestOAuthServer_RefreshToken(t *testing.T) {
+	server := newOAuthServer()
+	client := NewClient(t).To(server)
+
+	authorize := func() string {
+		location := client.Get("/authorize?" + url.Values{
+			"response_type":         {"code"},
+			"client_id":             {"app"},
+			"login_hint":            {"hexi"},
+			"code_challenge":        {pkceChallenge(OAuthVerifier)},
+			"code_challenge_method": {"S256"},
+		}.Encode()).Test().StatusFound().Header.Get(HeaderLocation)
+		callback, err := url.Parse(location)
+		assert.Nil(t, err)
+		return callback.Query().Get("code")
+	}
+	exchange := func(code, verifier string) *Response {
+		return tokenRequest(client, url.Values{"grant_type": {GrantAuthorizationCode}, "client_id": {"app"}, "code": {code}, "code_verifier": {verifier}}).Test()
+	}
+
+	exchange(authorize(), "wrong-verifier").StatusBadRequest().JSON().String("error", "invalid_grant")
+
+	code := authorize()
+	refresh, _ := exchange(code, OAuthVerifier).StatusOK().JSON().GetKey("refresh_token")
+	exchange(code, OAuthVerifier).StatusBadRequest()
+
+	refreshed := tokenRequest(client, url.Values{"grant_type": {GrantRefreshToken}, "client_id": {"app"}, "refresh_token": {refresh.String()}, "scope": {"email"}}).
+		Test().
+		StatusOK().
+		JSON()
+	refreshed.JWT("access_token", server.Key()).Subject("hexi").String("scope", "email")
+	tokenRequest(client, url.Values{"grant_type": {GrantRefreshToken}, "client_id": {"app"}, "refresh_token": {refresh.String()}}).
+		Test().
+		StatusBadRequest()
+
+	access, _ := refreshed.GetKey("access_token")
+	client.Get("/userinfo").Bearer(access.String()).Test().
+		StatusOK().
+		JSON().
+		String("sub", "hexi")
+	client.Get("/userinfo").Bearer("bogus").Test().
+		StatusUnauthorized().
+		WWWAuthenticate().
+		Param("Bearer", "error", "invalid_token")
+
+	var types []string
+	for _, grant := range server.Grants() {
+		types = append(types, grant.Type)
+	}
+	assert.Equal(t, []string{GrantAuthorizationCode, GrantRefreshToken}, types)
+}
@@ -0,0 +1,232 @@
+package htest
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	DefaultMaxPages = 100
+)
+
+type (
+	Page struct {
+		Index    int
+		URL      *url.URL
+		Response *Response
+		Body     []byte
+		Items    []gjson.Result
+	}
+
+	// PageStrategy returns the location of the page after page, or nil if page is the last one
+	PageStrategy interface {
+		Next(page *Page) *url.URL
+	}
+
+	PageStrategyFunc func(page *Page) *url.URL
+
+	Pagination struct {
+		request  *Request
+		strategy PageStrategy
+		items    string
+		maxPages int
+		pages    []*Page
+		walked   bool
+		*testing.T
+	}
+)
+
+func (fn PageStrategyFunc) Next(page *Page) *url.URL {
+	return fn(page)
+}
+
+// LinkNext follows the Link header with relation "next"
+func LinkNext() PageStrategy {
+	return PageStrategyFunc(func(page *Page) *url.URL {
+		href, exist := parseLinkHeader(page.Response.Header[HeaderLink])["next"]
+		if !exist {
+			return nil
+		}
+		location, err := page.URL.Parse(href)
+		if !assert.Nil(page.Response.t(), err) {
+			return nil
+		}
+		return location
+	})
+}
+
+// Cursor reads the next cursor at path of the body and sets it as query parameter param,
+// pagination stops when the cursor is missing or empty
+func Cursor(path, param string) PageStrategy {
+	return PageStrategyFunc(func(page *Page) *url.URL {
+		cursor := gjson.GetBytes(page.Body, path).String()
+		if cursor == "" {
+			return nil
+		}
+		return withQuery(page.URL, param, cursor)
+	})
+}
+
+// PageNumber increases query parameter param (1 if absent) until a page has no items
+func PageNumber(param string) PageStrategy {
+	return PageStrategyFunc(func(page *Page) *url.URL {
+		if len(page.Items) == 0 {
+			return nil
+		}
+		number, err := strconv.Atoi(page.URL.Query().Get(param))
+		if err != nil {
+			number = 1
+		}
+		return withQuery(page.URL, param, strconv.Itoa(number+1))
+	})
+}
+
+// Offset moves query parameter offsetParam forward by the items of each page,
+// pagination stops when a page has fewer items than query parameter limitParam (or none if it is absent)
+func Offset(offsetParam, limitParam string) PageStrategy {
+	return PageStrategyFunc(func(page *Page) *url.URL {
+		query := page.URL.Query()
+		offset, _ := strconv.Atoi(query.Get(offsetParam))
+		limit, err := strconv.Atoi(query.Get(limitParam))
+		if len(page.Items) == 0 || (err == nil && len(page.Items) < limit) {
+			return nil
+		}
+		return withQuery(page.URL, offsetParam, strconv.Itoa(offset+len(page.Items)))
+	})
+}
+
+func withQuery(location *url.URL, key, value string) *url.URL {
+	next := *location
+	query := next.Query()
+	query.Set(key, value)
+	next.RawQuery = query.Encode()
+	return &next
+}
+
+// Paginate walks pages from r lazily, the walk is issued on the first call of an assertion or getter
+func (r *Request) Paginate(strategy PageStrategy) *Pagination {
+	return &Pagination{
+		request:  r,
+		strategy: strategy,
+		maxPages: DefaultMaxPages,
+		T:        r.T,
+	}
+}
+
+// Items sets the path of the item array in each page, the whole body by default
+func (p *Pagination) Items(path string) *Pagination {
+	p.items = path
+	return p
+}
+
+// MaxPages fails the walk if it goes beyond n pages
+func (p *Pagination) MaxPages(n int) *Pagination {
+	p.maxPages = n
+	return p
+}
+
+func (p *Pagination) walk() []*Page {
+	if p.walked {
+		return p.pages
+	}
+	p.walked = true
+	for req := p.request; req != nil; {
+		if !assert.True(p.T, len(p.pages) < p.maxPages, "pagination goes beyond %d pages", p.maxPages) {
+			break
+		}
+		response := req.do()
+		if response.Response == nil {
+			break
+		}
+		page := &Page{
+			Index:    len(p.pages),
+			URL:      req.URL,
+			Response: response,
+			Body:     response.peek(),
+		}
+		if p.items == "" {
+			page.Items = gjson.ParseBytes(page.Body).Array()
+		} else {
+			page.Items = gjson.GetBytes(page.Body, p.items).Array()
+		}
+		p.pages = append(p.pages, page)
+		req = nil
+		if next := p.strategy.Next(page); next != nil {
+			req = p.request.clone(next)
+		}
+	}
+	return p.pages
+}
+
+func (p *Pagination) Pages() []*Page {
+	return p.walk()
+}
+
+func (p *Pagination) Each(fn func(page *Page)) *Pagination {
+	for _, page := range p.walk() {
+		fn(page)
+	}
+	return p
+}
+
+// All returns items of all pages in order
+func (p *Pagination) All() []gjson.Result {
+	var items []gjson.Result
+	for _, page := range p.walk() {
+		items = append(items, page.Items...)
+	}
+	return items
+}
+
+func (p *Pagination) PageCount(expect int) *Pagination {
+	assert.Equal(p.T, expect, len(p.walk()))
+	return p
+}
+
+func (p *Pagination) Count(expect int) *Pagination {
+	assert.Equal(p.T, expect, len(p.All()))
+	return p
+}
+
+// Unique asserts no two items across pages share the same value at key
+func (p *Pagination) Unique(key string) *Pagination {
+	seen := make(map[string]int)
+	for i, item := range p.All() {
+		id := item.Get(key).String()
+		if first, exist := seen[id]; exist {
+			assert.Fail(p.T, "duplicate item", "item %d and item %d have the same %s: %s", first, i, key, id)
+			continue
+		}
+		seen[id] = i
+	}
+	return p
+}
+
+// Ordered asserts values at key are in ascending order across pages
+func (p *Pagination) Ordered(key string) *Pagination {
+	return p.ordered(key, false)
+}
+
+// OrderedDesc asserts values at key are in descending order across pages
+func (p *Pagination) OrderedDesc(key string) *Pagination {
+	return p.ordered(key, true)
+}
+
+func (p *Pagination) ordered(key string, desc bool) *Pagination {
+	items := p.All()
+	for i := 1; i < len(items); i++ {
+		prev, cur := items[i-1].Get(key), items[i].Get(key)
+		if desc {
+			prev, cur = cur, prev
+		}
+		if cur.Less(prev, true) {
+			assert.Fail(p.T, "items out of order", "item %d (%s) and item %d (%s) are out of order by %s",
+				i-1, items[i-1].Get(key).Raw, i, items[i].Get(key).Raw, key)
+		}
+	}
+	return p
+}
@@ -0,0 +1,107 @@
+package htest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	PageItemsTotal = 5
+)
+
+func TestPagination_LinkNext(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/page/items?limit=2").
+		Paginate(LinkNext()).
+		Items("data").
+		PageCount(3).
+		Count(PageItemsTotal).
+		Unique("id").
+		Ordered("id")
+}
+
+func TestPagination_Cursor(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/page/items?limit=2").
+		Paginate(Cursor("next_cursor", "cursor")).
+		Items("data").
+		PageCount(3).
+		Count(PageItemsTotal).
+		Unique("id")
+}
+
+func TestPagination_PageNumber(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/page/items?limit=2").
+		Paginate(PageNumber("page")).
+		Items("data").
+		PageCount(4).
+		Count(PageItemsTotal).
+		Unique("id")
+}
+
+func TestPagination_Offset(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/page/items?limit=2&order=desc").
+		Paginate(Offset("offset", "limit")).
+		Items("data").
+		PageCount(3).
+		Count(PageItemsTotal).
+		Unique("id").
+		OrderedDesc("id")
+}
+
+func TestPagination_Each(t *testing.T) {
+	var pages []int
+	NewClient(t).
+		To(Mux).
+		Get("/page/items?limit=3").
+		Paginate(LinkNext()).
+		Items("data").
+		Each(func(page *Page) {
+			page.Response.StatusOK()
+			pages = append(pages, len(page.Items))
+		})
+	assert.Equal(t, []int{3, 2}, pages)
+}
+
+func PageItemsHandler(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil {
+		limit = 2
+	}
+	start := 0
+	if page, err := strconv.Atoi(query.Get("page")); err == nil {
+		start = (page - 1) * limit
+	}
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil {
+		start = offset
+	}
+	if cursor, err := strconv.Atoi(query.Get("cursor")); err == nil {
+		start = cursor
+	}
+	data := make([]map[string]int, 0)
+	for i := start; i < start+limit && i < PageItemsTotal; i++ {
+		id := i + 1
+		if query.Get("order") == "desc" {
+			id = PageItemsTotal - i
+		}
+		data = append(data, map[string]int{"id": id})
+	}
+	body := map[string]interface{}{"data": data}
+	if next := start + limit; next < PageItemsTotal {
+		body["next_cursor"] = strconv.Itoa(next)
+		w.Header().Set(HeaderLink, fmt.Sprintf(`</page/items?limit=%d&cursor=%d>; rel="next"`, limit, next))
+	}
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,141 @@
+package htest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+type (
+	// Proto converts a protobuf body to JSON for assertions, fields are named as in the .proto file
+	Proto struct {
+		*JSON
+		body    []byte
+		message proto.Message
+	}
+)
+
+// NewProto unmarshals body into message
+func NewProto(body []byte, message proto.Message, t *testing.T) *Proto {
+	return newProto(body, message, exchangeT{T: t})
+}
+
+func newProto(body []byte, message proto.Message, t exchangeT) *Proto {
+	var jsonBody []byte
+	if assert.Nil(t, proto.Unmarshal(body, message)) {
+		var err error
+		jsonBody, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(message)
+		assert.Nil(t, err)
+	}
+	return &Proto{
+		body:    body,
+		message: message,
+		JSON:    newJSON(jsonBody, t),
+	}
+}
+
+// Proto unmarshals the body into message
+func (r *Response) Proto(message proto.Message) *Proto {
+	body, err := ioutil.ReadAll(r.Response.Body)
+	r.Response.Body.Close()
+	assert.Nil(r.t(), err)
+	return newProto(body, message, r.t())
+}
+
+func (r *Request) ProtoBody(message proto.Message) *Request {
+	body, err := proto.Marshal(message)
+	assert.Nil(r.T, err)
+	return r.setBody(body, MIMEApplicationProtobuf)
+}
+
+// Equal asserts the message equals expect, the failure message is a field by field diff
+func (p *Proto) Equal(expect proto.Message) *Proto {
+	if diff := cmp.Diff(expect, p.message, protocmp.Transform()); diff != "" {
+		assert.Fail(p.exchangeT, "messages are not equal", "diff (-expect +actual):\n%s", diff)
+	}
+	return p
+}
+
+func (p *Proto) Message() proto.Message {
+	return p.message
+}
+
+func (p *Proto) Exist(key string) *Proto {
+	p.JSON.Exist(key)
+	return p
+}
+
+func (p *Proto) NotExist(key string) *Proto {
+	p.JSON.NotExist(key)
+	return p
+}
+
+func (p *Proto) String(key, expect string) *Proto {
+	p.JSON.String(key, expect)
+	return p
+}
+
+func (p *Proto) Int(key string, expect int64) *Proto {
+	p.JSON.Int(key, expect)
+	return p
+}
+
+func (p *Proto) True(key string) *Proto {
+	p.JSON.True(key)
+	return p
+}
+
+func (p *Proto) False(key string) *Proto {
+	p.JSON.False(key)
+	return p
+}
+
+func (p *Proto) Uint(key string, expect uint64) *Proto {
+	p.JSON.Uint(key, expect)
+	return p
+}
+
+func (p *Proto) Time(key string, expect time.Time) *Proto {
+	p.JSON.Time(key, expect)
+	return p
+}
+
+func (p *Proto) Float(key string, expect float64) *Proto {
+	p.JSON.Float(key, expect)
+	return p
+}
+
+func (p *Proto) Schema(schema string) *Proto {
+	p.JSON.Schema(schema)
+	return p
+}
+
+func (p *Proto) Empty() *Proto {
+	assert.Equal(p.exchangeT, "", string(p.Body()))
+	return p
+}
+
+func (p *Proto) NotEmpty() *Proto {
+	assert.NotEqual(p.exchangeT, "", string(p.Body()))
+	return p
+}
+
+func (p *Proto) Body() []byte {
+	return p.body
+}
+
+// Bind unmarshals the body into obj, which must be a proto.Message
+func (p *Proto) Bind(obj interface{}) error {
+	message, ok := obj.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", obj)
+	}
+	return proto.Unmarshal(p.body, message)
+}
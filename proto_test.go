@@ -0,0 +1,76 @@
+package htest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/apipb"
+)
+
+var (
+	testAPI = &apipb.Api{
+		Name: "htest",
+		Methods: []*apipb.Method{
+			{Name: "Get", RequestStreaming: true},
+		},
+	}
+)
+
+func TestProto_Assert(t *testing.T) {
+	api := new(apipb.Api)
+	NewClient(t).
+		To(Mux).
+		Post("/proto/api", nil).
+		ProtoBody(testAPI).
+		Test().
+		StatusOK().
+		HeaderContentType(MIMEApplicationProtobuf).
+		Proto(api).
+		Exist("version").
+		NotExist("mixins").
+		String("name", "htest").
+		String("version", "v1").
+		String("methods.0.name", "Get").
+		True("methods.0.request_streaming").
+		NotEmpty()
+	assert.Equal(t, "v1", api.Version)
+}
+
+func TestProto_Equal(t *testing.T) {
+	expect := proto.Clone(testAPI).(*apipb.Api)
+	expect.Version = "v1"
+	NewClient(t).
+		To(Mux).
+		Post("/proto/api", nil).
+		ProtoBody(testAPI).
+		Test().
+		StatusOK().
+		Proto(new(apipb.Api)).
+		Equal(expect)
+}
+
+func TestProto_Bind(t *testing.T) {
+	api := new(apipb.Api)
+	body, err := proto.Marshal(testAPI)
+	assert.Nil(t, err)
+	proto := NewProto(body, new(apipb.Api), t)
+	assert.Nil(t, proto.Bind(api))
+	assert.Equal(t, "htest", api.Name)
+	assert.NotNil(t, proto.Bind(struct{}{}))
+}
+
+func ProtoAPIHandler(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	api := new(apipb.Api)
+	if err != nil || proto.Unmarshal(body, api) != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	api.Version = "v1"
+	body, _ = proto.Marshal(api)
+	w.Header().Set(HeaderContentType, MIMEApplicationProtobuf)
+	w.Write(body)
+}
@@ -1,10 +1,15 @@
 package htest
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 )
 
 type (
@@ -12,6 +17,7 @@ type (
 		*http.Request
 		Handler http.Handler
 		*testing.T
+		client *Client
 	}
 )
 
@@ -32,19 +38,124 @@ func (r *Request) SetHeaders(headers map[string]string) *Request {
 	return r
 }
 
+// setBody replaces the body and its length, contentType is set if it is not empty
+func (r *Request) setBody(body []byte, contentType string) *Request {
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.ContentLength = int64(len(body))
+	if contentType != "" {
+		r.Header.Set(HeaderContentType, contentType)
+	}
+	return r
+}
+
+// bodyBytes reads the body and puts it back, so that it can be sent
+func (r *Request) bodyBytes() []byte {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	assert.Nil(r.T, err)
+	r.setBody(body, "")
+	return body
+}
+
 func (r *Request) Test() *Response {
 	if r.Handler == nil {
 		panic(MockNilError)
 	}
+	r.replayable()
+	return r.exchange((*Request).test)
+}
+
+func (r *Request) test() *Response {
 	recorder := httptest.NewRecorder()
-	r.Handler.ServeHTTP(recorder, r.Request)
-	return NewResponse(recorder.Result(), r.T)
+	writer := newChunkWriter(recorder)
+	r.Handler.ServeHTTP(writer, r.Request)
+	writer.cut()
+	response := r.response(recorder.Result())
+	response.inMemory = true
+	response.chunks = writer.chunks
+	response.flushes = writer.flushes
+	return response
 }
 
 func (r *Request) Send() *Response {
-	resp, err := (&http.Client{}).Do(r.Request)
+	r.replayable()
+	return r.exchange((*Request).send)
+}
+
+func (r *Request) send() *Response {
+	if r.client != nil && r.client.cassette != nil {
+		return r.response(r.client.cassette.roundTrip(r))
+	}
+	// ask for gzip as the transport does, but decode it by Response, which keeps Content-Encoding.
+	// Only the outgoing copy asks, r stays as the caller built it.
+	req := r.Request
+	if r.Header.Get(HeaderAcceptEncoding) == "" && r.Header.Get("Range") == "" && r.Method != HEAD {
+		req = r.Request.Clone(r.Context())
+		req.Header.Set(HeaderAcceptEncoding, EncodingGzip)
+	}
+	resp, err := (&http.Client{}).Do(req)
 	assert.Nil(r.T, err)
-	return NewResponse(resp, r.T)
+	return r.response(resp)
+}
+
+// exchange runs roundTrip on r, then on the retry with credentials if the response is a Digest challenge.
+// Each round trip is archived with its own timing, the challenge is archived before its body is discarded.
+func (r *Request) exchange(roundTrip func(*Request) *Response) *Response {
+	started := time.Now()
+	response := roundTrip(r)
+	r.archive(started, response)
+	if retry := r.digestRetry(response); retry != nil {
+		started = time.Now()
+		r, response = retry, roundTrip(retry)
+		r.archive(started, response)
+	}
+	return response
+}
+
+// do tests the mock server if there is one, otherwise sends the request to the real server
+func (r *Request) do() *Response {
+	if r.Handler == nil {
+		return r.Send()
+	}
+	return r.Test()
+}
+
+// clone copies r with the target replaced by location, the body is replayed when possible
+func (r *Request) clone(location *url.URL) *Request {
+	req := r.Request.Clone(r.Context())
+	req.URL = location
+	req.Host = location.Host
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		assert.Nil(r.T, err)
+		req.Body = body
+	}
+	return &Request{
+		Request: req,
+		Handler: r.Handler,
+		T:       r.T,
+		client:  r.client,
+	}
+}
+
+func (r *Request) response(resp *http.Response) *Response {
+	response := NewResponse(resp, r.T)
+	response.request = r
+	response.decode()
+	if response.Response != nil && response.Response.Body != nil {
+		response.captured = &capturedBody{ReadCloser: response.Response.Body, limit: DumpBodyLimit}
+		if r.client != nil && r.client.har != nil {
+			response.captured.limit = HARBodyLimit
+		}
+		response.Response.Body = response.captured
+	}
+	return response
 }
 
 func (r *Request) AddCookie(cookie *http.Cookie) *Request {
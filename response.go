@@ -1,6 +1,7 @@
 package htest
 
 import (
+	"bytes"
 	"crypto/md5"
 	"crypto/sha1"
 	"encoding/json"
@@ -8,6 +9,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"testing"
 )
 
@@ -15,6 +17,22 @@ type (
 	Response struct {
 		*http.Response
 		*testing.T
+		request *Request
+		chunks  []Chunk
+		flushes int
+
+		encodings []string
+		raw       *countingReader
+		decoded   *countingReader
+		wire      *bytes.Buffer
+
+		captured *capturedBody
+		inMemory bool
+	}
+
+	readCloser struct {
+		io.Reader
+		io.Closer
 	}
 )
 
@@ -26,7 +44,7 @@ func NewResponse(response *http.Response, t *testing.T) *Response {
 }
 
 func (r *Response) Code(statusCode int) *Response {
-	assert.Equal(r.T, statusCode, r.StatusCode)
+	assert.Equal(r.t(), statusCode, r.StatusCode)
 	return r
 }
 
@@ -219,33 +237,40 @@ func (r *Response) StatusNetworkAuthenticationRequired() *Response {
 func (r *Response) JSON() *JSON {
 	body, err := ioutil.ReadAll(r.Response.Body)
 	r.Response.Body.Close()
-	assert.Nil(r.T, err)
-	return NewJSON(body, r.T)
+	assert.Nil(r.t(), err)
+	return newJSON(body, r.t())
 }
 
 func (r *Response) XML() *XML {
 	body, err := ioutil.ReadAll(r.Response.Body)
 	r.Response.Body.Close()
-	assert.Nil(r.T, err)
-	return NewXML(body, r.T)
+	assert.Nil(r.t(), err)
+	return newXML(body, r.t())
+}
+
+func (r *Response) HTML() *HTML {
+	body, err := ioutil.ReadAll(r.Response.Body)
+	r.Response.Body.Close()
+	assert.Nil(r.t(), err)
+	return newHTML(body, r.t())
 }
 
 func (r *Response) Bytes() []byte {
 	body, err := ioutil.ReadAll(r.Response.Body)
 	r.Response.Body.Close()
-	assert.Nil(r.T, err)
+	assert.Nil(r.t(), err)
 	return body
 }
 
 func (r *Response) String() string {
 	body, err := ioutil.ReadAll(r.Response.Body)
 	r.Response.Body.Close()
-	assert.Nil(r.T, err)
+	assert.Nil(r.t(), err)
 	return string(body)
 }
 
 func (r *Response) Expect(expect string) {
-	assert.Equal(r.T, expect, r.String())
+	assert.Equal(r.t(), expect, r.String())
 }
 
 func (r *Response) MD5() *MD5 {
@@ -253,7 +278,7 @@ func (r *Response) MD5() *MD5 {
 	io.Copy(buf, r.Response.Body)
 	r.Response.Body.Close()
 	result := buf.Sum(nil)
-	return NewMD5(result, r.T)
+	return newMD5(result, r.t())
 }
 
 func (r *Response) SHA1() *SHA1 {
@@ -261,18 +286,95 @@ func (r *Response) SHA1() *SHA1 {
 	io.Copy(buf, r.Response.Body)
 	r.Response.Body.Close()
 	result := buf.Sum(nil)
-	return NewSHA1(result, r.T)
+	return newSHA1(result, r.t())
 }
 
 func (r *Response) Bind(obj interface{}) error {
 	body, err := ioutil.ReadAll(r.Response.Body)
 	r.Response.Body.Close()
-	assert.Nil(r.T, err)
+	assert.Nil(r.t(), err)
 	return json.Unmarshal(body, obj)
 }
 
+// peek reads the body and puts it back, so that it can be read again
+func (r *Response) peek() []byte {
+	body, err := ioutil.ReadAll(r.Response.Body)
+	if r.raw != nil {
+		// a decoder may stop before the end of its input, the wire bytes are kept whole
+		io.Copy(ioutil.Discard, r.raw)
+	}
+	r.Response.Body.Close()
+	assert.Nil(r.t(), err)
+	r.Response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// peekLimit reads at most limit bytes of the body without consuming them, more is true if the body is longer
+func (r *Response) peekLimit(limit int) (body []byte, more bool) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Response.Body, int64(limit)+1))
+	assert.Nil(r.t(), err)
+	r.Response.Body = &readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Response.Body), Closer: r.Response.Body}
+	if len(body) > limit {
+		return body[:limit], true
+	}
+	return body, false
+}
+
+// wireBytes returns the body as it is on the wire, before content decoding, the decoded body is kept for reading
+func (r *Response) wireBytes() []byte {
+	body := r.peek()
+	if r.wire == nil {
+		return body
+	}
+	return r.wire.Bytes()
+}
+
+// follow constructs a request on the same Client, targeting ref resolved against the request URL.
+// Cookies of the original request and cookies set by this response are carried over.
+func (r *Response) follow(method, ref string, body io.Reader) *Request {
+	client := Client{T: r.T}
+	base := new(url.URL)
+	var cookies []*http.Cookie
+	if r.request != nil {
+		if r.request.client != nil {
+			client = *r.request.client
+		}
+		base = r.request.URL
+		cookies = r.request.Cookies()
+	}
+	location, err := base.Parse(ref)
+	if !assert.Nil(r.t(), err) {
+		location = base
+	}
+	req := client.request(method, location.String(), body)
+	for _, cookie := range mergeCookies(cookies, r.Cookies()) {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func mergeCookies(cookies, updates []*http.Cookie) []*http.Cookie {
+	merged := make([]*http.Cookie, 0, len(cookies)+len(updates))
+	index := make(map[string]int)
+	for _, cookie := range append(cookies, updates...) {
+		if i, ok := index[cookie.Name]; ok {
+			merged[i] = cookie
+			continue
+		}
+		index[cookie.Name] = len(merged)
+		merged = append(merged, cookie)
+	}
+	result := merged[:0]
+	for _, cookie := range merged {
+		if cookie.MaxAge >= 0 {
+			result = append(result, &http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+	return result
+}
+
 func (r *Response) Headers(key, expect string) *Response {
-	assert.Equal(r.T, expect, r.Header.Get(key))
+	assert.Equal(r.t(), expect, r.Header.Get(key))
 	return r
 }
 
@@ -292,6 +394,10 @@ func (r *Response) HeaderAuthorization(expect string) *Response {
 	return r.Headers(HeaderAuthorization, expect)
 }
 
+func (r *Response) HeaderCacheControl(expect string) *Response {
+	return r.Headers(HeaderCacheControl, expect)
+}
+
 func (r *Response) HeaderContentDisposition(expect string) *Response {
 	return r.Headers(HeaderContentDisposition, expect)
 }
@@ -304,6 +410,10 @@ func (r *Response) HeaderContentLength(expect string) *Response {
 	return r.Headers(HeaderContentLength, expect)
 }
 
+func (r *Response) HeaderContentMD5(expect string) *Response {
+	return r.Headers(HeaderContentMD5, expect)
+}
+
 func (r *Response) HeaderContentType(expect string) *Response {
 	return r.Headers(HeaderContentType, expect)
 }
@@ -312,6 +422,14 @@ func (r *Response) HeaderCookie(expect string) *Response {
 	return r.Headers(HeaderCookie, expect)
 }
 
+func (r *Response) HeaderDigest(expect string) *Response {
+	return r.Headers(HeaderDigest, expect)
+}
+
+func (r *Response) HeaderETag(expect string) *Response {
+	return r.Headers(HeaderETag, expect)
+}
+
 func (r *Response) HeaderSetCookie(expect string) *Response {
 	return r.Headers(HeaderSetCookie, expect)
 }
@@ -324,10 +442,18 @@ func (r *Response) HeaderLastModified(expect string) *Response {
 	return r.Headers(HeaderLastModified, expect)
 }
 
+func (r *Response) HeaderLink(expect string) *Response {
+	return r.Headers(HeaderLink, expect)
+}
+
 func (r *Response) HeaderLocation(expect string) *Response {
 	return r.Headers(HeaderLocation, expect)
 }
 
+func (r *Response) HeaderReprDigest(expect string) *Response {
+	return r.Headers(HeaderReprDigest, expect)
+}
+
 func (r *Response) HeaderUpgrade(expect string) *Response {
 	return r.Headers(HeaderUpgrade, expect)
 }
@@ -435,3 +561,11 @@ func (r *Response) HeaderContentSecurityPolicy(expect string) *Response {
 func (r *Response) HeaderXCSRFToken(expect string) *Response {
 	return r.Headers(HeaderXCSRFToken, expect)
 }
+
+func (r *Response) HeaderXHubSignature256(expect string) *Response {
+	return r.Headers(HeaderXHubSignature256, expect)
+}
+
+func (r *Response) HeaderStripeSignature(expect string) *Response {
+	return r.Headers(HeaderStripeSignature, expect)
+}
@@ -0,0 +1,230 @@
+package htest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// scenario is a YAML file of steps run in order, values captured by a step are interpolated as {{name}} in later steps:
+	//
+	//	vars:
+	//	  name: hexi
+	//	steps:
+	//	  - name: create user
+	//	    method: POST
+	//	    path: /users
+	//	    body: {name: "{{name}}"}
+	//	    expect:
+	//	      status: 201
+	//	      headers: {Content-Type: application/json}
+	//	      json: {data.name: "{{name}}"}
+	//	      exists: [data.id]
+	//	    capture:
+	//	      id: $.data.id
+	//	      location: header:Location
+	//	  - method: GET
+	//	    path: /users/{{id}}
+	scenario struct {
+		Name  string                 `yaml:"name"`
+		Vars  map[string]interface{} `yaml:"vars"`
+		Steps []scenarioStep         `yaml:"steps"`
+	}
+
+	scenarioStep struct {
+		Name    string            `yaml:"name"`
+		Method  string            `yaml:"method"`
+		Path    string            `yaml:"path"`
+		Headers map[string]string `yaml:"headers"`
+		Body    interface{}       `yaml:"body"`
+		Expect  scenarioExpect    `yaml:"expect"`
+		Capture map[string]string `yaml:"capture"`
+	}
+
+	scenarioExpect struct {
+		Status  int                    `yaml:"status"`
+		Headers map[string]string      `yaml:"headers"`
+		JSON    map[string]interface{} `yaml:"json"`
+		Exists  []string               `yaml:"exists"`
+		Absent  []string               `yaml:"absent"`
+	}
+)
+
+// placeholder returns the variable name if s is a single {{name}}
+func placeholder(s string) (string, bool) {
+	match := variablePattern.FindStringSubmatch(s)
+	if match == nil || match[0] != strings.TrimSpace(s) {
+		return "", false
+	}
+	return match[1], true
+}
+
+// varString formats a variable for interpolation, a number as it is written in JSON
+func varString(value interface{}) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func stringVars(vars map[string]interface{}) map[string]string {
+	strs := make(map[string]string, len(vars))
+	for name, value := range vars {
+		strs[name] = varString(value)
+	}
+	return strs
+}
+
+// captureValue keeps a number as its JSON text, so that a large or exact one is interpolated as is
+func captureValue(result gjson.Result) interface{} {
+	if result.Type == gjson.Number {
+		return json.Number(result.Raw)
+	}
+	return result.Value()
+}
+
+// interpolateValue interpolates strings of a YAML value, a string which is a single {{name}} keeps the type of the variable
+func interpolateValue(value interface{}, vars map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if name, ok := placeholder(v); ok {
+			if typed, exist := vars[name]; exist {
+				return typed
+			}
+		}
+		return interpolate(v, stringVars(vars))
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = interpolateValue(item, vars)
+		}
+		return result
+	case map[interface{}]interface{}:
+		return interpolateValue(jsonCompatible(v), vars)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = interpolateValue(item, vars)
+		}
+		return result
+	}
+	return value
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RunScenario runs the steps of a YAML scenario file in order as subtests, by Request.Test if client has
+// a mock server, otherwise by Request.Send. A failed step stops the scenario. See scenario for the format.
+func RunScenario(t *testing.T, client *Client, path string) {
+	data, err := ioutil.ReadFile(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	var s scenario
+	if !assert.Nil(t, yaml.Unmarshal(data, &s), "%s is malformed", path) {
+		return
+	}
+	vars := make(map[string]interface{})
+	for name, value := range s.Vars {
+		vars[name] = value
+	}
+	for i, step := range s.Steps {
+		step := step
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("%d %s %s", i+1, step.Method, step.Path)
+		}
+		if !t.Run(name, func(t *testing.T) {
+			c := *client
+			c.T = t
+			step.run(&c, vars)
+		}) {
+			t.Logf("%s stops at step %q", path, name)
+			return
+		}
+	}
+}
+
+func (step scenarioStep) run(c *Client, vars map[string]interface{}) {
+	strs := stringVars(vars)
+	method := strings.ToUpper(step.Method)
+	if method == "" {
+		method = GET
+	}
+
+	var body io.Reader = bytes.NewReader(nil)
+	contentType := ""
+	switch value := interpolateValue(step.Body, vars).(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if !assert.Nil(c.T, err) {
+			return
+		}
+		body, contentType = bytes.NewReader(encoded), MIMEApplicationJSON
+	}
+	req := c.request(method, interpolate(step.Path, strs), body)
+	if contentType != "" {
+		req.Header.Set(HeaderContentType, contentType)
+	}
+	for key, value := range step.Headers {
+		req.Header.Set(key, interpolate(value, strs))
+	}
+
+	response := req.do()
+	if step.Expect.Status != 0 {
+		response.Code(step.Expect.Status)
+	}
+	for key, value := range step.Expect.Headers {
+		response.Headers(key, interpolate(value, strs))
+	}
+	if len(step.Expect.JSON)+len(step.Expect.Exists)+len(step.Expect.Absent)+len(step.Capture) == 0 {
+		return
+	}
+	result := newJSON(response.Bytes(), response.t())
+	for _, key := range sortedKeys(step.Expect.JSON) {
+		expect := varString(interpolateValue(step.Expect.JSON[key], vars))
+		jsonAssertion{path: jsonPath(key), op: "==", expect: expect}.assert(result)
+	}
+	for _, key := range step.Expect.Exists {
+		jsonAssertion{path: jsonPath(key), op: "exists"}.assert(result)
+	}
+	for _, key := range step.Expect.Absent {
+		jsonAssertion{path: jsonPath(key), op: "!exists"}.assert(result)
+	}
+
+	for name, source := range step.Capture {
+		if strings.HasPrefix(source, "header:") {
+			header := strings.TrimSpace(strings.TrimPrefix(source, "header:"))
+			value := response.Header.Get(header)
+			if assert.NotEmpty(c.T, value, "no header %s to capture as %s", header, name) {
+				vars[name] = value
+			}
+			continue
+		}
+		value, exist := result.GetKey(jsonPath(source))
+		if assert.True(c.T, exist, "no %s to capture as %s", source, name) {
+			vars[name] = captureValue(value)
+		}
+	}
+}
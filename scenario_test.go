@@ -0,0 +1,92 @@
+package htest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+// ids start above 1e6, which fmt prints in exponent form as float64
+var scenarioUsers = struct {
+	sync.Mutex
+	names map[int]string
+	next  int
+}{names: make(map[int]string), next: 1234566}
+
+func TestRunScenario(t *testing.T) {
+	RunScenario(t, NewClient(t).To(Mux), "testdata/scenario.yaml")
+}
+
+func TestInterpolateValue(t *testing.T) {
+	vars := map[string]interface{}{"id": int64(7), "name": "hexi"}
+	body := map[string]interface{}{
+		"id":    "{{id}}",
+		"label": "user {{id}}",
+		"tags":  []interface{}{"{{name}}", "{{unknown}}"},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"id":    int64(7),
+		"label": "user 7",
+		"tags":  []interface{}{"hexi", "{{unknown}}"},
+	}, interpolateValue(body, vars))
+}
+
+func TestCaptureValue(t *testing.T) {
+	result := NewJSON([]byte(`{"id": 1234567, "big": 9007199254740993, "ratio": 0.5, "name": "hexi"}`), t)
+	vars := make(map[string]interface{})
+	for _, key := range []string{"id", "big", "ratio", "name"} {
+		value, _ := result.GetKey(key)
+		vars[key] = captureValue(value)
+	}
+	assert.Equal(t, map[string]string{"id": "1234567", "big": "9007199254740993", "ratio": "0.5", "name": "hexi"}, stringVars(vars))
+	assert.Equal(t, "1234567", varString(float64(1234567)))
+
+	encoded, err := json.Marshal(interpolateValue(map[string]interface{}{"id": "{{id}}"}, vars))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"id":1234567}`, string(encoded))
+}
+
+// ScenarioUsersHandler creates a user from {"name": ...}
+func ScenarioUsersHandler(w http.ResponseWriter, req *http.Request) {
+	var user struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&user); err != nil || user.Name == "" {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	scenarioUsers.Lock()
+	scenarioUsers.next++
+	id := scenarioUsers.next
+	scenarioUsers.names[id] = user.Name
+	scenarioUsers.Unlock()
+	w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	w.Header().Set(HeaderLocation, fmt.Sprintf("/scenario/users/%d", id))
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"data": {"id": %d, "name": %q}}`, id, user.Name)
+}
+
+// ScenarioUserHandler gets or deletes the user of the id in path
+func ScenarioUserHandler(w http.ResponseWriter, req *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(req, "id"))
+	scenarioUsers.Lock()
+	defer scenarioUsers.Unlock()
+	name, exist := scenarioUsers.names[id]
+	if !exist {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	if req.Method == http.MethodDelete {
+		delete(scenarioUsers.names, id)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	fmt.Fprintf(w, `{"data": {"id": %d, "name": %q}}`, id, name)
+}
@@ -2,14 +2,41 @@ package htest
 
 import (
 	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
 	"io"
 	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+const (
+	// FailingEnv is set in the child process which runs a test expected to fail
+	FailingEnv = "HTEST_FAILING"
 )
 
 var (
 	Mux *chi.Mux
 )
 
+// failing skips a test which is expected to fail, unless expectFailure runs it
+func failing(t *testing.T) {
+	if os.Getenv(FailingEnv) == "" {
+		t.Skip("expected to fail, run by expectFailure")
+	}
+}
+
+// expectFailure runs the test named name verbosely in a child process, asserts it fails and returns its output.
+// args are added to the command line of the child, such as -test.v=false
+func expectFailure(t *testing.T, name string, args ...string) string {
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=^" + name + "$", "-test.v"}, args...)...)
+	cmd.Env = append(os.Environ(), FailingEnv+"=1")
+	output, err := cmd.CombinedOutput()
+	assert.NotNil(t, err, "%s passes", name)
+	assert.Contains(t, string(output), "--- FAIL: "+name)
+	return string(output)
+}
+
 func init() {
 	Mux = chi.NewRouter()
 	Mux.Get("/name", NameHandler)
@@ -27,6 +54,42 @@ func init() {
 	Mux.Get("/request/cookie", CookieHandler)
 	Mux.Get("/body/user", UserDataHandler)
 	Mux.Get("/xml_body/user", UserDataXMLHandler)
+	Mux.Get("/html/login", LoginPageHandler)
+	Mux.Post("/html/login", LoginHandler)
+	Mux.Get("/html/search", SearchHandler)
+	Mux.Get("/link/header", LinkHeaderHandler)
+	Mux.Get("/link/hal", HALHandler)
+	Mux.Get("/link/jsonapi", JSONAPIHandler)
+	Mux.Get("/page/items", PageItemsHandler)
+	Mux.Get("/sse/ticks", SSETicksHandler)
+	Mux.Get("/sse/forever", SSEForeverHandler)
+	Mux.Get("/ws/echo", WebSocketEchoHandler)
+	Mux.Get("/stream/export", ExportHandler)
+	Mux.Get("/ndjson/users", NDJSONHandler)
+	Mux.Get("/ndjson/forever", NDJSONForeverHandler)
+	Mux.Post("/msgpack/echo", MsgpackEchoHandler)
+	Mux.Post("/proto/api", ProtoAPIHandler)
+	Mux.Get("/config/yaml", ConfigYAMLHandler)
+	Mux.Get("/config/toml", ConfigTOMLHandler)
+	Mux.Get("/report/csv", ReportCSVHandler)
+	Mux.Post("/form/token", FormTokenHandler)
+	Mux.Get("/multipart/batch", MultipartBatchHandler)
+	Mux.Get("/artifact", ArtifactHandler)
+	Mux.Post("/webhook/github", GitHubWebhookHandler)
+	Mux.Get("/webhook/signed", SignedPayloadHandler)
+	Mux.Get("/compress/{encoding}", CompressHandler)
+	Mux.Head("/compress/{encoding}", CompressHandler)
+	Mux.Post("/decompress", DecompressHandler)
+	Mux.Get("/auth/basic", BasicAuthHandler)
+	Mux.Get("/auth/bearer", BearerAuthHandler)
+	Mux.Get("/auth/apikey", APIKeyHandler)
+	Mux.Get("/auth/digest", DigestAuthHandler)
+	Mux.Post("/auth/digest", DigestAuthHandler)
+	Mux.Post("/jwt/token", JWTTokenHandler)
+	Mux.Get("/jwt/me", JWTMeHandler)
+	Mux.Post("/scenario/users", ScenarioUsersHandler)
+	Mux.Get("/scenario/users/{id}", ScenarioUserHandler)
+	Mux.Delete("/scenario/users/{id}", ScenarioUserHandler)
 }
 
 func NameHandler(w http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,119 @@
+package htest
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Signature styles
+const (
+	// SignatureHex is the bare hex HMAC of the body
+	SignatureHex SignatureStyle = iota
+	// SignatureBase64 is the bare base64 HMAC of the body, as Shopify sends
+	SignatureBase64
+	// SignatureGitHub is the hex HMAC of the body prefixed by the algorithm, such as "sha256=..."
+	SignatureGitHub
+	// SignatureStripe is "t=<timestamp>,v1=<hex>", the HMAC is of "<timestamp>.<body>"
+	SignatureStripe
+)
+
+type (
+	SignatureStyle int
+)
+
+func newHMAC(algorithm, secret string) hash.Hash {
+	switch normalizeDigestAlgorithm(algorithm) {
+	case DigestMD5, DigestSHA1, DigestSHA256, DigestSHA512:
+		return hmac.New(func() hash.Hash { return newHash(algorithm) }, []byte(secret))
+	}
+	return nil
+}
+
+func signaturePrefix(algorithm string) string {
+	switch normalizeDigestAlgorithm(algorithm) {
+	case DigestSHA1:
+		return "sha1="
+	case DigestSHA256:
+		return "sha256="
+	case DigestSHA512:
+		return "sha512="
+	}
+	return normalizeDigestAlgorithm(algorithm) + "="
+}
+
+// sign computes the HMAC of body in style, timestamp is only used by SignatureStripe
+func sign(style SignatureStyle, algorithm, secret string, body []byte, timestamp string) string {
+	mac := newHMAC(algorithm, secret)
+	if mac == nil {
+		return ""
+	}
+	if style == SignatureStripe {
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+	sum := mac.Sum(nil)
+	switch style {
+	case SignatureBase64:
+		return base64.StdEncoding.EncodeToString(sum)
+	case SignatureGitHub:
+		return signaturePrefix(algorithm) + hex.EncodeToString(sum)
+	case SignatureStripe:
+		return "t=" + timestamp + ",v1=" + hex.EncodeToString(sum)
+	}
+	return hex.EncodeToString(sum)
+}
+
+// Signature asserts header carries the HMAC of the body with secret, SignatureHex by default
+func (r *Response) Signature(header, algorithm, secret string, style ...SignatureStyle) *Response {
+	s := SignatureHex
+	if len(style) > 0 {
+		s = style[0]
+	}
+	if !assert.NotNil(r.t(), newHMAC(algorithm, secret), "unsupported hmac algorithm %q", algorithm) {
+		return r
+	}
+	value := r.Header.Get(header)
+	// the signature covers the body as it is sent, before content decoding
+	body := r.wireBytes()
+	if s != SignatureStripe {
+		assert.Equal(r.t(), sign(s, algorithm, secret, body, ""), value)
+		return r
+	}
+	var timestamp string
+	var signatures []string
+	for _, entry := range strings.Split(value, ",") {
+		pair := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		switch pair[0] {
+		case "t":
+			timestamp = pair[1]
+		case "v1":
+			signatures = append(signatures, pair[1])
+		}
+	}
+	expect := strings.TrimPrefix(sign(s, algorithm, secret, body, timestamp), "t="+timestamp+",v1=")
+	assert.Contains(r.t(), signatures, expect, "no v1 signature matches in %s header", header)
+	return r
+}
+
+// Sign sets header to the HMAC of the body with secret, SignatureHex by default
+func (r *Request) Sign(header, algorithm, secret string, style ...SignatureStyle) *Request {
+	s := SignatureHex
+	if len(style) > 0 {
+		s = style[0]
+	}
+	if !assert.NotNil(r.T, newHMAC(algorithm, secret), "unsupported hmac algorithm %q", algorithm) {
+		return r
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	return r.SetHeader(header, sign(s, algorithm, secret, r.bodyBytes(), timestamp))
+}
@@ -0,0 +1,97 @@
+package htest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const (
+	WebhookSecret    = "webhook-secret"
+	WebhookTimestamp = "1519257600"
+)
+
+func TestRequest_Sign(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.
+		Post("/webhook/github", strings.NewReader(UserData)).
+		Sign(HeaderXHubSignature256, DigestSHA256, WebhookSecret, SignatureGitHub).
+		Test().
+		StatusOK().
+		JSON().
+		String("name", "hexi")
+
+	client.
+		Post("/webhook/github", strings.NewReader(UserData)).
+		Sign(HeaderXHubSignature256, DigestSHA256, "wrong-secret", SignatureGitHub).
+		Test().
+		StatusUnauthorized()
+}
+
+func TestResponse_Signature(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	client.Get("/webhook/signed?style=hex").Test().
+		Signature("X-Signature", DigestSHA256, WebhookSecret).
+		JSON().
+		String("name", "hexi")
+	client.Get("/webhook/signed?style=base64").Test().
+		Signature("X-Signature", "sha256", WebhookSecret, SignatureBase64)
+	client.Get("/webhook/signed?style=github").Test().
+		Signature(HeaderXHubSignature256, DigestSHA256, WebhookSecret, SignatureGitHub)
+	client.Get("/webhook/signed?style=stripe").Test().
+		Signature(HeaderStripeSignature, DigestSHA256, WebhookSecret, SignatureStripe)
+	client.Get("/webhook/signed?style=github&encoding=gzip").Test().
+		Signature(HeaderXHubSignature256, DigestSHA256, WebhookSecret, SignatureGitHub).
+		Compressed(EncodingGzip).
+		JSON().
+		String("name", "hexi")
+}
+
+func GitHubWebhookHandler(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	mac := hmac.New(sha256.New, []byte(WebhookSecret))
+	mac.Write(body)
+	expect := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expect), []byte(req.Header.Get(HeaderXHubSignature256))) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	w.Write(body)
+}
+
+func SignedPayloadHandler(w http.ResponseWriter, req *http.Request) {
+	body := []byte(UserData)
+	if req.URL.Query().Get("encoding") == EncodingGzip {
+		buf := new(bytes.Buffer)
+		writer := gzip.NewWriter(buf)
+		writer.Write(body)
+		writer.Close()
+		body = buf.Bytes()
+		w.Header().Set(HeaderContentEncoding, EncodingGzip)
+	}
+	mac := hmac.New(sha256.New, []byte(WebhookSecret))
+	switch req.URL.Query().Get("style") {
+	case "hex":
+		mac.Write(body)
+		w.Header().Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	case "base64":
+		mac.Write(body)
+		w.Header().Set("X-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	case "github":
+		mac.Write(body)
+		w.Header().Set(HeaderXHubSignature256, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	case "stripe":
+		mac.Write([]byte(WebhookTimestamp + "."))
+		mac.Write(body)
+		w.Header().Set(HeaderStripeSignature, fmt.Sprintf("t=%s,v1=%x,v0=legacy", WebhookTimestamp, mac.Sum(nil)))
+	}
+	w.Write(body)
+}
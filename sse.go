@@ -0,0 +1,199 @@
+package htest
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	DefaultSSETimeout = 5 * time.Second
+)
+
+type (
+	SSEEvent struct {
+		ID    string
+		Event string
+		Data  string
+		Retry int
+	}
+
+	// SSE reads a text/event-stream body event by event, Close it when done to stop reading
+	SSE struct {
+		body    io.ReadCloser
+		events  chan *SSEEvent
+		errs    chan error
+		done    chan struct{}
+		close   sync.Once
+		timeout time.Duration
+		exchangeT
+	}
+)
+
+func NewSSE(body io.ReadCloser, t *testing.T) *SSE {
+	return newSSE(body, exchangeT{T: t})
+}
+
+func newSSE(body io.ReadCloser, t exchangeT) *SSE {
+	s := &SSE{
+		body:      body,
+		events:    make(chan *SSEEvent),
+		errs:      make(chan error, 1),
+		done:      make(chan struct{}),
+		timeout:   DefaultSSETimeout,
+		exchangeT: t,
+	}
+	go s.read()
+	return s
+}
+
+func (r *Response) SSE() *SSE {
+	return newSSE(r.Response.Body, r.t())
+}
+
+// read parses the stream as https://html.spec.whatwg.org/multipage/server-sent-events.html describes,
+// it stops when the stream ends or the SSE is closed. The last retry is kept for later events
+func (s *SSE) read() {
+	defer close(s.events)
+	var (
+		lastID string
+		event  string
+		data   []string
+		retry  int
+	)
+	reader := bufio.NewReader(s.body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err != io.EOF {
+				s.errs <- err
+			}
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if data != nil {
+				if event == "" {
+					event = "message"
+				}
+				select {
+				case s.events <- &SSEEvent{ID: lastID, Event: event, Data: strings.Join(data, "\n"), Retry: retry}:
+				case <-s.done:
+					return
+				}
+			}
+			event, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				lastID = value
+			}
+		case "retry":
+			if n, err := strconv.Atoi(value); err == nil {
+				retry = n
+			}
+		}
+	}
+}
+
+// Timeout sets how long Next and expectations wait for an event
+func (s *SSE) Timeout(timeout time.Duration) *SSE {
+	s.timeout = timeout
+	return s
+}
+
+// Next waits for the next event, it returns nil if the stream ends or the wait times out
+func (s *SSE) Next() *SSEEvent {
+	select {
+	case event, ok := <-s.events:
+		if !ok {
+			select {
+			case err := <-s.errs:
+				assert.Nil(s.exchangeT, err)
+			default:
+			}
+			return nil
+		}
+		return event
+	case <-time.After(s.timeout):
+		assert.Fail(s.exchangeT, "timeout", "no event within %s", s.timeout)
+		return nil
+	}
+}
+
+func (s *SSE) next() *SSEEvent {
+	event := s.Next()
+	if event == nil {
+		assert.Fail(s.exchangeT, "no event", "stream ends before the expected event")
+		return &SSEEvent{}
+	}
+	return event
+}
+
+// ExpectEvent asserts type and data of the next event
+func (s *SSE) ExpectEvent(event, data string) *SSE {
+	next := s.next()
+	assert.Equal(s.exchangeT, event, next.Event)
+	assert.Equal(s.exchangeT, data, next.Data)
+	return s
+}
+
+func (s *SSE) ExpectData(data string) *SSE {
+	assert.Equal(s.exchangeT, data, s.next().Data)
+	return s
+}
+
+func (s *SSE) ExpectID(id string) *SSE {
+	assert.Equal(s.exchangeT, id, s.next().ID)
+	return s
+}
+
+func (s *SSE) ExpectRetry(retry int) *SSE {
+	assert.Equal(s.exchangeT, retry, s.next().Retry)
+	return s
+}
+
+// ExpectJSON asserts type of the next event and returns its data as JSON
+func (s *SSE) ExpectJSON(event string) *JSON {
+	next := s.next()
+	assert.Equal(s.exchangeT, event, next.Event)
+	return newJSON([]byte(next.Data), s.exchangeT)
+}
+
+// ExpectEnd asserts the server closes the stream without sending more events
+func (s *SSE) ExpectEnd() {
+	select {
+	case event, ok := <-s.events:
+		assert.False(s.exchangeT, ok, "unexpected event %+v", event)
+	case <-time.After(s.timeout):
+		assert.Fail(s.exchangeT, "timeout", "stream does not end within %s", s.timeout)
+	}
+	s.Close()
+}
+
+// Close disconnects from the server and stops reading
+func (s *SSE) Close() {
+	s.close.Do(func() {
+		close(s.done)
+		s.body.Close()
+	})
+}
@@ -0,0 +1,121 @@
+package htest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	SSEDisconnected = make(chan struct{}, 1)
+)
+
+func TestSSE_ExpectEvent(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/sse/ticks").
+		Stream().
+		StatusOK().
+		HeaderContentType(MIMETextEventStream).
+		SSE().
+		ExpectEvent("tick", `{"n": 1}`).
+		ExpectID("2").
+		ExpectData(`{"n": 3}`).
+		ExpectRetry(1000).
+		ExpectRetry(1000).
+		ExpectEnd()
+}
+
+func TestSSE_Recorder(t *testing.T) {
+	sse := NewClient(t).
+		To(Mux).
+		Get("/sse/ticks").
+		Test().
+		StatusOK().
+		SSE()
+	sse.ExpectJSON("tick").Int("n", 1)
+	event := sse.Next()
+	assert.Equal(t, "2", event.ID)
+	assert.Equal(t, "tick", event.Event)
+	sse.Next()
+	event = sse.Next()
+	assert.Equal(t, "message", event.Event)
+	assert.Equal(t, "a\nb", event.Data)
+	assert.Equal(t, "3", event.ID)
+	assert.Equal(t, 1000, event.Retry)
+	event = sse.Next()
+	assert.Equal(t, "c", event.Data)
+	assert.Equal(t, 1000, event.Retry)
+	sse.ExpectEnd()
+}
+
+func TestSSE_Close(t *testing.T) {
+	sse := NewClient(t).
+		To(Mux).
+		Get("/sse/forever").
+		Stream().
+		StatusOK().
+		SSE().
+		Timeout(time.Second).
+		ExpectEvent("ping", "1")
+	sse.Close()
+	select {
+	case <-SSEDisconnected:
+	case <-time.After(time.Second):
+		t.Error("handler is not disconnected")
+	}
+}
+
+func TestSSE_Close_StopsReading(t *testing.T) {
+	client := NewClient(t).To(Mux)
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		sse := client.Get("/sse/ticks").Test().SSE()
+		sse.ExpectEvent("tick", `{"n": 1}`)
+		sse.Close()
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, runtime.NumGoroutine() < before+5, "%d goroutines before, %d after", before, runtime.NumGoroutine())
+}
+
+func TestSSE_Send(t *testing.T) {
+	server := httptest.NewServer(Mux)
+	defer server.Close()
+	sse := NewClient(t).
+		Get(server.URL+"/sse/forever").
+		Send().
+		StatusOK().
+		SSE().
+		ExpectEvent("ping", "1")
+	sse.Close()
+	select {
+	case <-SSEDisconnected:
+	case <-time.After(time.Second):
+		t.Error("handler is not disconnected")
+	}
+}
+
+func SSETicksHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(HeaderContentType, MIMETextEventStream)
+	flusher := w.(http.Flusher)
+	flusher.Flush()
+	for i := 1; i <= 3; i++ {
+		fmt.Fprintf(w, "id: %d\nevent: tick\ndata: {\"n\": %d}\n\n", i, i)
+		flusher.Flush()
+	}
+	io.WriteString(w, ": comment\nretry: 1000\ndata: a\ndata: b\n\ndata: c\n\n")
+}
+
+func SSEForeverHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(HeaderContentType, MIMETextEventStream)
+	io.WriteString(w, "event: ping\ndata: 1\n\n")
+	w.(http.Flusher).Flush()
+	<-req.Context().Done()
+	SSEDisconnected <- struct{}{}
+}
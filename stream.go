@@ -0,0 +1,185 @@
+package htest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// Chunk is the body written between two flushes, Elapsed is the time from the request to the flush
+	Chunk struct {
+		Data    []byte
+		Elapsed time.Duration
+	}
+
+	// chunkWriter records the body written between flushes, the handler returning flushes the rest
+	chunkWriter struct {
+		http.ResponseWriter
+		start   time.Time
+		pending bytes.Buffer
+		chunks  []Chunk
+		flushes int
+	}
+
+	// streamWriter is a http.ResponseWriter which pipes the body to the reader side as it is written
+	streamWriter struct {
+		header    http.Header
+		committed http.Header
+		code      int
+		pipe      *io.PipeWriter
+		ready     chan struct{}
+		once      sync.Once
+	}
+
+	// streamBody cancels the request context of the handler when it is closed
+	streamBody struct {
+		*io.PipeReader
+		cancel context.CancelFunc
+	}
+)
+
+func newChunkWriter(w http.ResponseWriter) *chunkWriter {
+	return &chunkWriter{
+		ResponseWriter: w,
+		start:          time.Now(),
+	}
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.pending.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *chunkWriter) Flush() {
+	w.flushes++
+	w.cut()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *chunkWriter) cut() {
+	if w.pending.Len() == 0 {
+		return
+	}
+	w.chunks = append(w.chunks, Chunk{
+		Data:    append([]byte(nil), w.pending.Bytes()...),
+		Elapsed: time.Since(w.start),
+	})
+	w.pending.Reset()
+}
+
+func newStreamWriter(pipe *io.PipeWriter) *streamWriter {
+	return &streamWriter{
+		header: make(http.Header),
+		pipe:   pipe,
+		ready:  make(chan struct{}),
+	}
+}
+
+func (w *streamWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *streamWriter) WriteHeader(code int) {
+	w.once.Do(func() {
+		w.code = code
+		w.committed = w.header.Clone()
+		close(w.ready)
+	})
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.pipe.Write(p)
+}
+
+func (w *streamWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (b *streamBody) Close() error {
+	b.cancel()
+	return b.PipeReader.Close()
+}
+
+// Stream serves the mock server in background, it returns as soon as the handler writes headers, body or flushes.
+// The body is read while the handler is writing it, closing the body cancels the context of the request.
+func (r *Request) Stream() *Response {
+	if r.Handler == nil {
+		panic(MockNilError)
+	}
+	started := time.Now()
+	ctx, cancel := context.WithCancel(r.Context())
+	reader, writer := io.Pipe()
+	w := newStreamWriter(writer)
+	go func() {
+		// a panic out of the test goroutine would crash the test binary, fail the test and break the body instead
+		defer func() {
+			if err := recover(); err != nil {
+				r.Errorf("handler panics: %v\n%s", err, debug.Stack())
+				w.WriteHeader(http.StatusInternalServerError)
+				writer.CloseWithError(fmt.Errorf("htest: handler panics: %v", err))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			writer.Close()
+		}()
+		r.Handler.ServeHTTP(w, r.Request.WithContext(ctx))
+	}()
+	<-w.ready
+	response := r.response(&http.Response{
+		Status:        fmt.Sprintf("%d %s", w.code, http.StatusText(w.code)),
+		StatusCode:    w.code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.committed,
+		Body:          &streamBody{PipeReader: reader, cancel: cancel},
+		ContentLength: -1,
+		Request:       r.Request,
+	})
+	r.archive(started, response)
+	return response
+}
+
+// Chunks returns the body split by flushes of the handler, only Request.Test records them
+func (r *Response) Chunks() []Chunk {
+	return r.chunks
+}
+
+// Flushes asserts how many times the handler flushes
+func (r *Response) Flushes(expect int) *Response {
+	assert.Equal(r.t(), expect, r.flushes)
+	return r
+}
+
+// MinChunks asserts the body is written in at least n chunks
+func (r *Response) MinChunks(n int) *Response {
+	assert.True(r.t(), len(r.chunks) >= n, "expect at least %d chunks, got %d", n, len(r.chunks))
+	return r
+}
+
+// FirstChunkWithin asserts the first chunk is flushed within d since the request
+func (r *Response) FirstChunkWithin(d time.Duration) *Response {
+	if assert.NotEmpty(r.t(), r.chunks, "no chunk is written") {
+		assert.True(r.t(), r.chunks[0].Elapsed <= d, "first chunk arrives after %s, expect within %s", r.chunks[0].Elapsed, d)
+	}
+	return r
+}
+
+// ChunkWithin asserts the i-th chunk is flushed within d since the request
+func (r *Response) ChunkWithin(i int, d time.Duration) *Response {
+	if assert.True(r.t(), i < len(r.chunks), "no chunk %d, got %d chunks", i, len(r.chunks)) {
+		assert.True(r.t(), r.chunks[i].Elapsed <= d, "chunk %d arrives after %s, expect within %s", i, r.chunks[i].Elapsed, d)
+	}
+	return r
+}
@@ -0,0 +1,82 @@
+package htest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	ExportInterval = 20 * time.Millisecond
+	// StreamTimeout is a generous upper bound of the export, which is only late on a loaded machine
+	StreamTimeout = 5 * time.Second
+)
+
+func TestResponse_Chunks(t *testing.T) {
+	response := NewClient(t).
+		To(Mux).
+		Get("/stream/export").
+		Test().
+		StatusOK().
+		Flushes(3).
+		MinChunks(4).
+		FirstChunkWithin(StreamTimeout).
+		ChunkWithin(3, StreamTimeout)
+	chunks := response.Chunks()
+	assert.Len(t, chunks, 4)
+	assert.Equal(t, "{\"n\": 0}\n", string(chunks[0].Data))
+	assert.Equal(t, "{\"n\": 3}\n", string(chunks[3].Data))
+	// the handler sleeps between chunks, so only the gaps have a lower bound
+	for i := 1; i < len(chunks); i++ {
+		assert.True(t, chunks[i].Elapsed-chunks[i-1].Elapsed >= ExportInterval, "chunk %d", i)
+	}
+	assert.Equal(t, "{\"n\": 0}\n{\"n\": 1}\n{\"n\": 2}\n{\"n\": 3}\n", response.String())
+}
+
+func TestRequest_Stream(t *testing.T) {
+	start := time.Now()
+	response := NewClient(t).
+		To(Mux).
+		Get("/stream/export").
+		Stream().
+		StatusOK()
+	streamed := time.Since(start)
+	body, err := ioutil.ReadAll(response.Body)
+	assert.Nil(t, err)
+	// Stream returns at the first chunk, the other three arrive one interval apart after it
+	assert.True(t, time.Since(start)-streamed >= 2*ExportInterval, "Stream waits for the handler")
+	assert.Equal(t, "{\"n\": 0}\n{\"n\": 1}\n{\"n\": 2}\n{\"n\": 3}\n", string(body))
+}
+
+func TestRequest_StreamPanics(t *testing.T) {
+	output := expectFailure(t, "TestRequest_StreamPanicsFailing")
+	assert.Contains(t, output, "handler panics: boom")
+}
+
+func TestRequest_StreamPanicsFailing(t *testing.T) {
+	failing(t)
+	response := NewClient(t).
+		ToFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		}).
+		Get("/").
+		Stream().
+		Code(http.StatusInternalServerError)
+	_, err := ioutil.ReadAll(response.Body)
+	assert.EqualError(t, err, "htest: handler panics: boom")
+}
+
+func ExportHandler(w http.ResponseWriter, req *http.Request) {
+	flusher := w.(http.Flusher)
+	for i := 0; i < 4; i++ {
+		if i > 0 {
+			flusher.Flush()
+			time.Sleep(ExportInterval)
+		}
+		fmt.Fprintf(w, "{\"n\": %d}\n", i)
+	}
+}
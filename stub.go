@@ -0,0 +1,374 @@
+package htest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+// Stub faults
+const (
+	// FaultConnectionReset closes the connection without a response
+	FaultConnectionReset StubFault = iota + 1
+	// FaultMalformedResponse writes garbage instead of a HTTP response
+	FaultMalformedResponse
+	// FaultTruncatedBody announces a longer body than it writes, then closes the connection
+	FaultTruncatedBody
+)
+
+type (
+	StubFault int
+
+	// Stub is a fake upstream server, requests are matched against routes in the order they are added
+	Stub struct {
+		server    *httptest.Server
+		mu        sync.Mutex
+		routes    []*StubRoute
+		calls     []*StubCall
+		unmatched []*StubCall
+		*testing.T
+	}
+
+	// StubRoute matches requests and replies with its responses in sequence, the last one repeats
+	StubRoute struct {
+		stub      *Stub
+		method    string
+		path      string
+		query     map[string]string
+		header    map[string]string
+		matchers  []func(body []byte) bool
+		responses []*stubResponse
+		calls     []*StubCall
+	}
+
+	stubResponse struct {
+		code   int
+		header http.Header
+		body   []byte
+		delay  time.Duration
+		fault  StubFault
+	}
+
+	// StubCall is a request received by the stub, its body is kept for assertions
+	StubCall struct {
+		*http.Request
+		body []byte
+		*testing.T
+	}
+)
+
+// NewStub starts a stub server, Close it when the test ends
+func NewStub(t *testing.T) *Stub {
+	s := &Stub{T: t}
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+func (s *Stub) URL() string {
+	return s.server.URL
+}
+
+func (s *Stub) Close() {
+	s.server.Close()
+}
+
+// On adds a route matching method and path, an empty method matches any method and a path ending with * matches the prefix
+func (s *Stub) On(method, path string) *StubRoute {
+	route := &StubRoute{
+		stub:   s,
+		method: method,
+		path:   path,
+		query:  make(map[string]string),
+		header: make(map[string]string),
+	}
+	s.mu.Lock()
+	s.routes = append(s.routes, route)
+	s.mu.Unlock()
+	return route
+}
+
+func (s *Stub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	call := &StubCall{Request: req, body: body, T: s.T}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	var (
+		route    *StubRoute
+		response *stubResponse
+	)
+	for _, candidate := range s.routes {
+		if candidate.match(req, body) {
+			route = candidate
+			break
+		}
+	}
+	if route == nil {
+		s.unmatched = append(s.unmatched, call)
+		s.mu.Unlock()
+		http.Error(w, fmt.Sprintf("htest: no stub route for %s %s", req.Method, req.URL), http.StatusNotImplemented)
+		return
+	}
+	route.calls = append(route.calls, call)
+	if len(route.responses) > 0 {
+		response = route.responses[len(route.responses)-1]
+		if len(route.calls) <= len(route.responses) {
+			response = route.responses[len(route.calls)-1]
+		}
+	}
+	s.mu.Unlock()
+
+	if response == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	response.serve(w, req, s.T)
+}
+
+func (r *stubResponse) serve(w http.ResponseWriter, req *http.Request, t *testing.T) {
+	if r.delay > 0 {
+		select {
+		case <-time.After(r.delay):
+		case <-req.Context().Done():
+			return
+		}
+	}
+	if r.fault != 0 {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			assert.Fail(t, "stub fault", "%s %s: faults need a hijackable connection, got %T", req.Method, req.URL, w)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		conn, buf, err := hijacker.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		switch r.fault {
+		case FaultMalformedResponse:
+			buf.WriteString("not a http response\r\n\r\n")
+		case FaultTruncatedBody:
+			fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n", len(r.body)+1024)
+			buf.Write(r.body)
+		}
+		buf.Flush()
+		return
+	}
+	for key, values := range r.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(r.code)
+	w.Write(r.body)
+}
+
+func (r *StubRoute) match(req *http.Request, body []byte) bool {
+	if r.method != "" && r.method != req.Method {
+		return false
+	}
+	if strings.HasSuffix(r.path, "*") {
+		if !strings.HasPrefix(req.URL.Path, strings.TrimSuffix(r.path, "*")) {
+			return false
+		}
+	} else if r.path != req.URL.Path {
+		return false
+	}
+	query := req.URL.Query()
+	for key, value := range r.query {
+		if query.Get(key) != value {
+			return false
+		}
+	}
+	for key, value := range r.header {
+		if req.Header.Get(key) != value {
+			return false
+		}
+	}
+	for _, matcher := range r.matchers {
+		if !matcher(body) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *StubRoute) Query(key, value string) *StubRoute {
+	r.query[key] = value
+	return r
+}
+
+func (r *StubRoute) Header(key, value string) *StubRoute {
+	r.header[key] = value
+	return r
+}
+
+// BodyJSON matches requests whose body is JSON equal to expect, formatting and key order are ignored
+func (r *StubRoute) BodyJSON(expect string) *StubRoute {
+	var want interface{}
+	assert.Nil(r.stub.T, json.Unmarshal([]byte(expect), &want), "invalid json matcher")
+	r.matchers = append(r.matchers, func(body []byte) bool {
+		var got interface{}
+		return json.Unmarshal(body, &got) == nil && reflect.DeepEqual(want, got)
+	})
+	return r
+}
+
+// BodyJSONPath matches requests whose body has expect at path, compared as gjson strings
+func (r *StubRoute) BodyJSONPath(path, expect string) *StubRoute {
+	r.matchers = append(r.matchers, func(body []byte) bool {
+		result := gjson.GetBytes(body, path)
+		return result.Exists() && result.String() == expect
+	})
+	return r
+}
+
+// Reply adds a response to the sequence
+func (r *StubRoute) Reply(code int, body string) *StubRoute {
+	r.stub.mu.Lock()
+	defer r.stub.mu.Unlock()
+	r.reply(code, body)
+	return r
+}
+
+func (r *StubRoute) reply(code int, body string) *stubResponse {
+	response := &stubResponse{code: code, header: make(http.Header), body: []byte(body)}
+	r.responses = append(r.responses, response)
+	return response
+}
+
+// ReplyJSON adds a response of v encoded as JSON to the sequence
+func (r *StubRoute) ReplyJSON(code int, v interface{}) *StubRoute {
+	body, err := json.Marshal(v)
+	assert.Nil(r.stub.T, err)
+	r.Reply(code, string(body))
+	return r.ReplyHeader(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+}
+
+// Fault adds a response which breaks the connection to the sequence
+func (r *StubRoute) Fault(fault StubFault) *StubRoute {
+	r.stub.mu.Lock()
+	defer r.stub.mu.Unlock()
+	r.reply(http.StatusOK, "").fault = fault
+	return r
+}
+
+// last returns the last response added, the stub must be locked
+func (r *StubRoute) last() *stubResponse {
+	if len(r.responses) == 0 {
+		return r.reply(http.StatusOK, "")
+	}
+	return r.responses[len(r.responses)-1]
+}
+
+// ReplyHeader sets a header of the last response added
+func (r *StubRoute) ReplyHeader(key, value string) *StubRoute {
+	r.stub.mu.Lock()
+	defer r.stub.mu.Unlock()
+	r.last().header.Set(key, value)
+	return r
+}
+
+// Delay holds the last response added for d, a canceled request stops waiting
+func (r *StubRoute) Delay(d time.Duration) *StubRoute {
+	r.stub.mu.Lock()
+	defer r.stub.mu.Unlock()
+	r.last().delay = d
+	return r
+}
+
+// Called asserts how many requests the route receives
+func (r *StubRoute) Called(expect int) *StubRoute {
+	r.stub.mu.Lock()
+	defer r.stub.mu.Unlock()
+	assert.Equal(r.stub.T, expect, len(r.calls), "%s %s is called %d times", r.method, r.path, len(r.calls))
+	return r
+}
+
+func (r *StubRoute) Calls() []*StubCall {
+	r.stub.mu.Lock()
+	defer r.stub.mu.Unlock()
+	return append([]*StubCall(nil), r.calls...)
+}
+
+// Call returns the i-th request received by the route
+func (r *StubRoute) Call(i int) *StubCall {
+	return callAt(r.stub.T, r.Calls(), i)
+}
+
+func callAt(t *testing.T, calls []*StubCall, i int) *StubCall {
+	if !assert.True(t, i >= 0 && i < len(calls), "no call %d, got %d calls", i, len(calls)) {
+		req, _ := http.NewRequest(GET, "/", nil)
+		return &StubCall{Request: req, T: t}
+	}
+	return calls[i]
+}
+
+// Called asserts how many requests the stub receives, matched or not
+func (s *Stub) Called(expect int) *Stub {
+	assert.Equal(s.T, expect, len(s.Calls()))
+	return s
+}
+
+func (s *Stub) Calls() []*StubCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*StubCall(nil), s.calls...)
+}
+
+func (s *Stub) Call(i int) *StubCall {
+	return callAt(s.T, s.Calls(), i)
+}
+
+// NoUnmatched asserts every request matches a route
+func (s *Stub) NoUnmatched() *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, call := range s.unmatched {
+		assert.Fail(s.T, "unmatched request", "no stub route for %s %s", call.Request.Method, call.URL)
+	}
+	return s
+}
+
+// ExpectMethod asserts the method, the embedded Request keeps its Method, Header, Body and Form fields
+func (c *StubCall) ExpectMethod(expect string) *StubCall {
+	assert.Equal(c.T, expect, c.Request.Method)
+	return c
+}
+
+func (c *StubCall) Path(expect string) *StubCall {
+	assert.Equal(c.T, expect, c.URL.Path)
+	return c
+}
+
+func (c *StubCall) Query(key, expect string) *StubCall {
+	assert.Equal(c.T, expect, c.URL.Query().Get(key))
+	return c
+}
+
+func (c *StubCall) Headers(key, expect string) *StubCall {
+	assert.Equal(c.T, expect, c.Request.Header.Get(key))
+	return c
+}
+
+func (c *StubCall) BodyBytes() []byte {
+	return c.body
+}
+
+func (c *StubCall) JSON() *JSON {
+	return NewJSON(c.body, c.T)
+}
+
+func (c *StubCall) FormValues() *FormValues {
+	return NewFormValues(c.body, c.T)
+}
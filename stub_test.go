@@ -0,0 +1,145 @@
+package htest
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ProfileHandler loads the user from the upstream service, then publishes an event to it
+func ProfileHandler(upstream string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		upstreamReq, _ := http.NewRequest(GET, upstream+"/users/1?expand=true", nil)
+		upstreamReq.Header.Set("X-Token", "abc")
+		resp, err := http.DefaultClient.Do(upstreamReq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			http.Error(w, resp.Status, http.StatusBadGateway)
+			return
+		}
+		var user struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(resp.Body).Decode(&user)
+		event, _ := json.Marshal(map[string]interface{}{"type": "viewed", "user": map[string]string{"name": user.Name}})
+		if resp, err := http.Post(upstream+"/events", MIMEApplicationJSON, strings.NewReader(string(event))); err == nil {
+			resp.Body.Close()
+		}
+		io.WriteString(w, `{"name": "`+user.Name+`"}`)
+	}
+}
+
+func TestStub(t *testing.T) {
+	stub := NewStub(t)
+	defer stub.Close()
+	users := stub.On(GET, "/users/*").
+		Query("expand", "true").
+		Header("X-Token", "abc").
+		ReplyJSON(http.StatusOK, map[string]string{"name": "hexi"})
+	events := stub.On(POST, "/events").
+		BodyJSONPath("user.name", "hexi").
+		Reply(http.StatusAccepted, "")
+
+	client := NewClient(t).ToFunc(ProfileHandler(stub.URL()))
+	client.Get("/profile").Test().StatusOK().JSON().String("name", "hexi")
+	client.Get("/profile").Test().StatusOK()
+
+	users.Called(2).Call(0).Path("/users/1").Headers("X-Token", "abc")
+	call := events.Called(2).Call(1)
+	assert.Equal(t, POST, call.Method)
+	assert.Equal(t, MIMEApplicationJSON, call.Header.Get(HeaderContentType))
+	call.
+		ExpectMethod(POST).
+		JSON().
+		String("type", "viewed").
+		String("user.name", "hexi")
+	stub.Called(4).NoUnmatched()
+}
+
+func TestStubRoute_BodyJSON(t *testing.T) {
+	stub := NewStub(t)
+	defer stub.Close()
+	route := stub.On(POST, "/events").BodyJSON(`{"type": "viewed", "user": {"name": "hexi"}}`).Reply(http.StatusAccepted, "")
+
+	resp, err := http.Post(stub.URL()+"/events", MIMEApplicationJSON, strings.NewReader(`{"user":{"name":"hexi"},"type":"viewed"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp, err = http.Post(stub.URL()+"/events", MIMEApplicationJSON, strings.NewReader(`{"type":"viewed"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+
+	route.Called(1)
+	stub.Called(2)
+	assert.Len(t, stub.unmatched, 1)
+}
+
+func TestStubRoute_Sequence(t *testing.T) {
+	stub := NewStub(t)
+	defer stub.Close()
+	stub.On(GET, "/users/1").
+		Reply(http.StatusServiceUnavailable, "").
+		ReplyHeader("Retry-After", "1").
+		ReplyJSON(http.StatusOK, map[string]string{"name": "hexi"})
+
+	client := NewClient(t).ToFunc(ProfileHandler(stub.URL()))
+	client.Get("/profile").Test().StatusBadGateway()
+	client.Get("/profile").Test().StatusOK()
+	client.Get("/profile").Test().StatusOK()
+
+	resp, err := http.Get(stub.URL() + "/users/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", resp.Header.Get("Retry-After"))
+	assert.Equal(t, MIMEApplicationJSONCharsetUTF8, resp.Header.Get(HeaderContentType))
+}
+
+func TestStubRoute_Delay(t *testing.T) {
+	stub := NewStub(t)
+	defer stub.Close()
+	stub.On(GET, "/slow").Reply(http.StatusOK, "late").Delay(time.Second)
+
+	_, err := (&http.Client{Timeout: 50 * time.Millisecond}).Get(stub.URL() + "/slow")
+	assert.NotNil(t, err)
+}
+
+func TestStubRoute_Fault(t *testing.T) {
+	stub := NewStub(t)
+	defer stub.Close()
+	stub.On(GET, "/reset").Fault(FaultConnectionReset)
+	stub.On(GET, "/malformed").Fault(FaultMalformedResponse)
+	stub.On(GET, "/truncated").Fault(FaultTruncatedBody)
+
+	_, err := http.Get(stub.URL() + "/reset")
+	assert.NotNil(t, err)
+	_, err = http.Get(stub.URL() + "/malformed")
+	assert.NotNil(t, err)
+
+	resp, err := http.Get(stub.URL() + "/truncated")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = ioutil.ReadAll(resp.Body)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestStubRoute_Fault_NotHijackable(t *testing.T) {
+	output := expectFailure(t, "TestStubRoute_Fault_NotHijackableFailing")
+	assert.Contains(t, output, "faults need a hijackable connection")
+	assert.NotContains(t, output, "panic")
+}
+
+func TestStubRoute_Fault_NotHijackableFailing(t *testing.T) {
+	failing(t)
+	stub := NewStub(t)
+	defer stub.Close()
+	stub.On(GET, "/reset").Fault(FaultConnectionReset)
+	NewClient(t).To(stub).Get("/reset").Test().StatusInternalServerError()
+}
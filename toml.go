@@ -0,0 +1,112 @@
+package htest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// TOML converts a TOML body to JSON for assertions, Bind decodes the original body
+	TOML struct {
+		*JSON
+		body []byte
+	}
+)
+
+func NewTOML(body []byte, t *testing.T) *TOML {
+	return newTOML(body, exchangeT{T: t})
+}
+
+func newTOML(body []byte, t exchangeT) *TOML {
+	value := make(map[string]interface{})
+	var jsonBody []byte
+	if _, err := toml.Decode(string(body), &value); assert.Nil(t, err) && len(value) > 0 {
+		jsonBody, err = json.Marshal(value)
+		assert.Nil(t, err)
+	}
+	return &TOML{
+		body: body,
+		JSON: newJSON(jsonBody, t),
+	}
+}
+
+func (r *Response) TOML() *TOML {
+	body, err := ioutil.ReadAll(r.Response.Body)
+	r.Response.Body.Close()
+	assert.Nil(r.t(), err)
+	return newTOML(body, r.t())
+}
+
+func (tm *TOML) Exist(key string) *TOML {
+	tm.JSON.Exist(key)
+	return tm
+}
+
+func (tm *TOML) NotExist(key string) *TOML {
+	tm.JSON.NotExist(key)
+	return tm
+}
+
+func (tm *TOML) String(key, expect string) *TOML {
+	tm.JSON.String(key, expect)
+	return tm
+}
+
+func (tm *TOML) Int(key string, expect int64) *TOML {
+	tm.JSON.Int(key, expect)
+	return tm
+}
+
+func (tm *TOML) True(key string) *TOML {
+	tm.JSON.True(key)
+	return tm
+}
+
+func (tm *TOML) False(key string) *TOML {
+	tm.JSON.False(key)
+	return tm
+}
+
+func (tm *TOML) Uint(key string, expect uint64) *TOML {
+	tm.JSON.Uint(key, expect)
+	return tm
+}
+
+func (tm *TOML) Time(key string, expect time.Time) *TOML {
+	tm.JSON.Time(key, expect)
+	return tm
+}
+
+func (tm *TOML) Float(key string, expect float64) *TOML {
+	tm.JSON.Float(key, expect)
+	return tm
+}
+
+func (tm *TOML) Schema(schema string) *TOML {
+	tm.JSON.Schema(schema)
+	return tm
+}
+
+func (tm *TOML) Empty() *TOML {
+	assert.Equal(tm.exchangeT, "", string(tm.Body()))
+	return tm
+}
+
+func (tm *TOML) NotEmpty() *TOML {
+	assert.NotEqual(tm.exchangeT, "", string(tm.Body()))
+	return tm
+}
+
+func (tm *TOML) Body() []byte {
+	return tm.body
+}
+
+func (tm *TOML) Bind(obj interface{}) error {
+	_, err := toml.Decode(string(tm.body), obj)
+	return err
+}
@@ -0,0 +1,60 @@
+package htest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	TOMLAssertData = `
+number = 1
+time = 2018-02-22T00:00:00Z
+ok = true
+no = false
+
+[[servers]]
+host = "localhost"
+port = 8080
+`
+)
+
+func TestTOML_Assert(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/config/toml").
+		Test().
+		StatusOK().
+		TOML().
+		Exist("servers").
+		NotExist("clients").
+		Int("number", 1).
+		Uint("number", 1).
+		Float("number", 1).
+		Time("time", AssertDataTime).
+		True("ok").
+		False("no").
+		String("servers.0.host", "localhost").
+		Int("servers.0.port", 8080).
+		NotEmpty()
+}
+
+func TestTOML_Empty(t *testing.T) {
+	NewTOML([]byte(""), t).
+		Empty().
+		JSON.Empty()
+}
+
+func TestTOML_Bind(t *testing.T) {
+	config := new(Config)
+	assert.Nil(t, NewTOML([]byte(TOMLAssertData), t).Bind(config))
+	assert.Equal(t, 1, config.Number)
+	assert.True(t, config.OK)
+}
+
+func ConfigTOMLHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(HeaderContentType, MIMEApplicationTOML)
+	io.WriteString(w, TOMLAssertData)
+}
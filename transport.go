@@ -0,0 +1,154 @@
+package htest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// Transport is a http.RoundTripper which serves outbound requests by in-process handlers chosen by host,
+	// unmatched requests fail unless there is a passthrough
+	Transport struct {
+		mu          sync.Mutex
+		hosts       map[string]http.Handler
+		passthrough http.RoundTripper
+		original    http.RoundTripper
+		unmatched   []*http.Request
+		*testing.T
+	}
+)
+
+func NewTransport(t *testing.T) *Transport {
+	return &Transport{
+		hosts: make(map[string]http.Handler),
+		T:     t,
+	}
+}
+
+// Handle serves requests to host by handler, host is "name" for any port or "name:port"
+func (tr *Transport) Handle(host string, handler http.Handler) *Transport {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.hosts[host] = handler
+	return tr
+}
+
+func (tr *Transport) HandleFunc(host string, handlerFunc http.HandlerFunc) *Transport {
+	return tr.Handle(host, handlerFunc)
+}
+
+// Reply answers every request to host with a canned response
+func (tr *Transport) Reply(host string, code int, body string, header ...http.Header) *Transport {
+	return tr.HandleFunc(host, func(w http.ResponseWriter, req *http.Request) {
+		if len(header) > 0 {
+			for key, values := range header[0] {
+				w.Header()[key] = values
+			}
+		}
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	})
+}
+
+// Passthrough sends unmatched requests by rt instead of failing them,
+// http.DefaultTransport after Install means the transport tr replaces
+func (tr *Transport) Passthrough(rt http.RoundTripper) *Transport {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.passthrough = rt
+	return tr
+}
+
+func (tr *Transport) passthroughTransport() http.RoundTripper {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.passthrough == http.RoundTripper(tr) {
+		return tr.original
+	}
+	return tr.passthrough
+}
+
+func (tr *Transport) handler(req *http.Request) http.Handler {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if handler, exist := tr.hosts[req.URL.Host]; exist {
+		return handler
+	}
+	if handler, exist := tr.hosts[req.URL.Hostname()]; exist {
+		return handler
+	}
+	return nil
+}
+
+func (tr *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	handler := tr.handler(req)
+	if handler == nil {
+		if passthrough := tr.passthroughTransport(); passthrough != nil {
+			return passthrough.RoundTrip(req)
+		}
+		tr.mu.Lock()
+		tr.unmatched = append(tr.unmatched, req)
+		tr.mu.Unlock()
+		// a RoundTripper closes the body, even on errors
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("htest: no handler for host %q of %s %s", req.URL.Host, req.Method, req.URL)
+	}
+
+	// a server request carries what a handler expects, the body must not be shared with the caller
+	served := req.Clone(req.Context())
+	served.RequestURI = req.URL.RequestURI()
+	if served.Host == "" {
+		served.Host = req.URL.Host
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		served.Body = ioutil.NopCloser(bytes.NewReader(body))
+	} else {
+		served.Body = http.NoBody
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, served)
+	resp := recorder.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// Client returns a *http.Client using tr
+func (tr *Transport) Client() *http.Client {
+	return &http.Client{Transport: tr}
+}
+
+// Install replaces http.DefaultTransport by tr until the returned restore is called, as in defer tr.Install()()
+func (tr *Transport) Install() (restore func()) {
+	original := http.DefaultTransport
+	tr.mu.Lock()
+	tr.original = original
+	tr.mu.Unlock()
+	http.DefaultTransport = tr
+	return func() {
+		http.DefaultTransport = original
+	}
+}
+
+// NoUnmatched asserts every outbound request is served by a handler
+func (tr *Transport) NoUnmatched() *Transport {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for _, req := range tr.unmatched {
+		assert.Fail(tr.T, "unmatched request", "no handler for %s %s", req.Method, req.URL)
+	}
+	return tr
+}
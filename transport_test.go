@@ -0,0 +1,92 @@
+package htest
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport_Install(t *testing.T) {
+	stub := NewStub(t)
+	defer stub.Close()
+	stub.On(GET, "/users/1").ReplyJSON(http.StatusOK, map[string]string{"name": "hexi"})
+	events := stub.On(POST, "/events").Reply(http.StatusAccepted, "")
+
+	transport := NewTransport(t).Handle("users.internal", stub)
+	func() {
+		defer transport.Install()()
+		NewClient(t).ToFunc(ProfileHandler("http://users.internal")).
+			Get("/profile").
+			Test().
+			StatusOK().
+			JSON().
+			String("name", "hexi")
+	}()
+
+	assert.NotEqual(t, transport, http.DefaultTransport)
+	events.Called(1).Call(0).JSON().String("type", "viewed")
+	transport.NoUnmatched()
+}
+
+func TestTransport_Passthrough_Installed(t *testing.T) {
+	stub := NewStub(t)
+	defer stub.Close()
+	stub.On(GET, "/users/1").Reply(http.StatusOK, "hexi")
+
+	transport := NewTransport(t)
+	defer transport.Install()()
+	transport.Passthrough(http.DefaultTransport)
+	resp, err := http.Get(stub.URL() + "/users/1")
+	if assert.Nil(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "hexi", string(body))
+	}
+	transport.NoUnmatched()
+}
+
+func TestTransport_Client(t *testing.T) {
+	transport := NewTransport(t).
+		Handle("api.test", Mux).
+		Reply("cdn.test", http.StatusOK, "body { }", http.Header{HeaderContentType: {"text/css"}})
+	client := transport.Client()
+
+	resp, err := client.Get("http://api.test/name")
+	if assert.Nil(t, err) {
+		NewResponse(resp, t).StatusOK().JSON().String("name", "hexi")
+	}
+
+	resp, err = client.Get("https://cdn.test:8443/site.css")
+	if assert.Nil(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, "body { }", string(body))
+		assert.Equal(t, "text/css", resp.Header.Get(HeaderContentType))
+	}
+
+	_, err = client.Get("http://unknown.test/")
+	assert.NotNil(t, err)
+	assert.Len(t, transport.unmatched, 1)
+}
+
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestTransport_Unmatched_ClosesBody(t *testing.T) {
+	body := &closeTracker{Reader: strings.NewReader(UserData)}
+	req, err := http.NewRequest(POST, "http://unknown.test/", body)
+	assert.Nil(t, err)
+	_, err = NewTransport(t).RoundTrip(req)
+	assert.NotNil(t, err)
+	assert.True(t, body.closed)
+}
@@ -0,0 +1,205 @@
+package htest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	DefaultWebSocketTimeout = 5 * time.Second
+)
+
+type (
+	// WebSocket is a websocket connection to the mock server (or a real server), frames are asserted in order
+	WebSocket struct {
+		conn     *websocket.Conn
+		server   *httptest.Server
+		frames   chan wsFrame
+		timeout  time.Duration
+		Response *Response
+		*testing.T
+	}
+
+	wsFrame struct {
+		messageType int
+		data        []byte
+		closeCode   int
+		err         error
+	}
+)
+
+// WebSocket upgrades path on the mock server served over a local listener,
+// path can also be an absolute ws:// or wss:// url of a real server
+func (c Client) WebSocket(path string, header ...http.Header) *WebSocket {
+	ws := &WebSocket{
+		frames:  make(chan wsFrame, 16),
+		timeout: DefaultWebSocketTimeout,
+		T:       c.T,
+	}
+	location := path
+	if !strings.HasPrefix(path, "ws://") && !strings.HasPrefix(path, "wss://") {
+		if c.handler == nil {
+			panic(MockNilError)
+		}
+		ws.server = httptest.NewServer(c.handler)
+		location = "ws" + strings.TrimPrefix(ws.server.URL, "http") + path
+	}
+	var requestHeader http.Header
+	if len(header) > 0 {
+		requestHeader = header[0]
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial(location, requestHeader)
+	if resp != nil {
+		ws.Response = NewResponse(resp, c.T)
+	}
+	if !assert.Nil(c.T, err) {
+		ws.closeServer()
+		return ws
+	}
+	ws.conn = conn
+	conn.SetPongHandler(func(data string) error {
+		ws.frames <- wsFrame{messageType: websocket.PongMessage, data: []byte(data)}
+		return nil
+	})
+	go ws.read()
+	return ws
+}
+
+func (ws *WebSocket) read() {
+	defer close(ws.frames)
+	for {
+		messageType, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			if closeErr, ok := err.(*websocket.CloseError); ok {
+				ws.frames <- wsFrame{messageType: websocket.CloseMessage, data: []byte(closeErr.Text), closeCode: closeErr.Code}
+			} else {
+				ws.frames <- wsFrame{err: err}
+			}
+			return
+		}
+		ws.frames <- wsFrame{messageType: messageType, data: data}
+	}
+}
+
+func (ws *WebSocket) Timeout(timeout time.Duration) *WebSocket {
+	ws.timeout = timeout
+	return ws
+}
+
+func (ws *WebSocket) write(messageType int, data []byte) *WebSocket {
+	if ws.conn == nil {
+		return ws
+	}
+	assert.Nil(ws.T, ws.conn.SetWriteDeadline(time.Now().Add(ws.timeout)))
+	assert.Nil(ws.T, ws.conn.WriteMessage(messageType, data))
+	return ws
+}
+
+func (ws *WebSocket) SendText(text string) *WebSocket {
+	return ws.write(websocket.TextMessage, []byte(text))
+}
+
+func (ws *WebSocket) SendBinary(data []byte) *WebSocket {
+	return ws.write(websocket.BinaryMessage, data)
+}
+
+func (ws *WebSocket) SendJSON(v interface{}) *WebSocket {
+	data, err := json.Marshal(v)
+	assert.Nil(ws.T, err)
+	return ws.write(websocket.TextMessage, data)
+}
+
+func (ws *WebSocket) Ping(data string) *WebSocket {
+	return ws.write(websocket.PingMessage, []byte(data))
+}
+
+// CloseWith sends a close frame with code and reason, the server is expected to answer with ExpectClose
+func (ws *WebSocket) CloseWith(code int, reason string) *WebSocket {
+	return ws.write(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+}
+
+// next waits for the next frame of messageType
+func (ws *WebSocket) next(messageType int) wsFrame {
+	if ws.conn == nil {
+		return wsFrame{}
+	}
+	select {
+	case frame, ok := <-ws.frames:
+		if !ok {
+			assert.Fail(ws.T, "connection closed", "connection is closed before the expected frame")
+			return wsFrame{}
+		}
+		if !assert.Nil(ws.T, frame.err) {
+			return wsFrame{}
+		}
+		assert.Equal(ws.T, frameTypeName(messageType), frameTypeName(frame.messageType))
+		return frame
+	case <-time.After(ws.timeout):
+		assert.Fail(ws.T, "timeout", "no %s frame within %s", frameTypeName(messageType), ws.timeout)
+		return wsFrame{}
+	}
+}
+
+func (ws *WebSocket) ExpectText(expect string) *WebSocket {
+	assert.Equal(ws.T, expect, string(ws.next(websocket.TextMessage).data))
+	return ws
+}
+
+func (ws *WebSocket) ExpectBinary(expect []byte) *WebSocket {
+	assert.Equal(ws.T, expect, ws.next(websocket.BinaryMessage).data)
+	return ws
+}
+
+// ExpectJSON waits for the next text frame and returns it as JSON
+func (ws *WebSocket) ExpectJSON() *JSON {
+	return NewJSON(ws.next(websocket.TextMessage).data, ws.T)
+}
+
+func (ws *WebSocket) ExpectPong(expect string) *WebSocket {
+	assert.Equal(ws.T, expect, string(ws.next(websocket.PongMessage).data))
+	return ws
+}
+
+func (ws *WebSocket) ExpectClose(code int) *WebSocket {
+	assert.Equal(ws.T, code, ws.next(websocket.CloseMessage).closeCode)
+	return ws
+}
+
+// Close closes the connection and the local listener
+func (ws *WebSocket) Close() {
+	if ws.conn != nil {
+		ws.conn.Close()
+		for range ws.frames {
+		}
+	}
+	ws.closeServer()
+}
+
+func (ws *WebSocket) closeServer() {
+	if ws.server != nil {
+		ws.server.Close()
+	}
+}
+
+func frameTypeName(messageType int) string {
+	switch messageType {
+	case websocket.TextMessage:
+		return "text"
+	case websocket.BinaryMessage:
+		return "binary"
+	case websocket.CloseMessage:
+		return "close"
+	case websocket.PingMessage:
+		return "ping"
+	case websocket.PongMessage:
+		return "pong"
+	}
+	return "unknown"
+}
@@ -0,0 +1,92 @@
+package htest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	upgrader = websocket.Upgrader{}
+)
+
+func TestWebSocket_Text(t *testing.T) {
+	ws := NewClient(t).
+		To(Mux).
+		WebSocket("/ws/echo")
+	defer ws.Close()
+	ws.Response.StatusSwitchingProtocols()
+	ws.SendText("hello").
+		ExpectText("hello").
+		SendBinary([]byte{0, 1, 2}).
+		ExpectBinary([]byte{0, 1, 2})
+}
+
+func TestWebSocket_JSON(t *testing.T) {
+	ws := NewClient(t).
+		To(Mux).
+		WebSocket("/ws/echo")
+	defer ws.Close()
+	ws.SendJSON(map[string]string{"name": "hexi"}).
+		ExpectJSON().
+		String("name", "hexi")
+}
+
+func TestWebSocket_Ping(t *testing.T) {
+	ws := NewClient(t).
+		To(Mux).
+		WebSocket("/ws/echo")
+	defer ws.Close()
+	ws.Ping("ping").
+		SendText("after ping").
+		ExpectPong("ping").
+		ExpectText("after ping")
+}
+
+func TestWebSocket_Close(t *testing.T) {
+	ws := NewClient(t).
+		To(Mux).
+		WebSocket("/ws/echo")
+	defer ws.Close()
+	ws.SendText("bye").
+		ExpectClose(websocket.ClosePolicyViolation)
+
+	ws = NewClient(t).
+		To(Mux).
+		WebSocket("/ws/echo")
+	defer ws.Close()
+	ws.CloseWith(websocket.CloseNormalClosure, "done").
+		ExpectClose(websocket.CloseNormalClosure)
+}
+
+func TestWebSocket_RealServer(t *testing.T) {
+	server := httptest.NewServer(Mux)
+	defer server.Close()
+	ws := NewClient(t).
+		WebSocket("ws" + strings.TrimPrefix(server.URL, "http") + "/ws/echo")
+	defer ws.Close()
+	ws.SendText("hello").
+		ExpectText("hello")
+}
+
+func WebSocketEchoHandler(w http.ResponseWriter, req *http.Request) {
+	conn, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if string(data) == "bye" {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "bye"))
+			return
+		}
+		conn.WriteMessage(messageType, data)
+	}
+}
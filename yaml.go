@@ -0,0 +1,112 @@
+package htest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// YAML converts a YAML body to JSON for assertions, Bind decodes the original body
+	YAML struct {
+		*JSON
+		body []byte
+	}
+)
+
+func NewYAML(body []byte, t *testing.T) *YAML {
+	return newYAML(body, exchangeT{T: t})
+}
+
+func newYAML(body []byte, t exchangeT) *YAML {
+	var value interface{}
+	var jsonBody []byte
+	if assert.Nil(t, yaml.Unmarshal(body, &value)) && value != nil {
+		var err error
+		jsonBody, err = json.Marshal(jsonCompatible(value))
+		assert.Nil(t, err)
+	}
+	return &YAML{
+		body: body,
+		JSON: newJSON(jsonBody, t),
+	}
+}
+
+func (r *Response) YAML() *YAML {
+	body, err := ioutil.ReadAll(r.Response.Body)
+	r.Response.Body.Close()
+	assert.Nil(r.t(), err)
+	return newYAML(body, r.t())
+}
+
+func (y *YAML) Exist(key string) *YAML {
+	y.JSON.Exist(key)
+	return y
+}
+
+func (y *YAML) NotExist(key string) *YAML {
+	y.JSON.NotExist(key)
+	return y
+}
+
+func (y *YAML) String(key, expect string) *YAML {
+	y.JSON.String(key, expect)
+	return y
+}
+
+func (y *YAML) Int(key string, expect int64) *YAML {
+	y.JSON.Int(key, expect)
+	return y
+}
+
+func (y *YAML) True(key string) *YAML {
+	y.JSON.True(key)
+	return y
+}
+
+func (y *YAML) False(key string) *YAML {
+	y.JSON.False(key)
+	return y
+}
+
+func (y *YAML) Uint(key string, expect uint64) *YAML {
+	y.JSON.Uint(key, expect)
+	return y
+}
+
+func (y *YAML) Time(key string, expect time.Time) *YAML {
+	y.JSON.Time(key, expect)
+	return y
+}
+
+func (y *YAML) Float(key string, expect float64) *YAML {
+	y.JSON.Float(key, expect)
+	return y
+}
+
+func (y *YAML) Schema(schema string) *YAML {
+	y.JSON.Schema(schema)
+	return y
+}
+
+func (y *YAML) Empty() *YAML {
+	assert.Equal(y.exchangeT, "", string(y.Body()))
+	return y
+}
+
+func (y *YAML) NotEmpty() *YAML {
+	assert.NotEqual(y.exchangeT, "", string(y.Body()))
+	return y
+}
+
+func (y *YAML) Body() []byte {
+	return y.body
+}
+
+func (y *YAML) Bind(obj interface{}) error {
+	return yaml.Unmarshal(y.body, obj)
+}
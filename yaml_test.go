@@ -0,0 +1,69 @@
+package htest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	YAMLAssertData = `
+number: 1
+time: 2018-02-22T00:00:00Z
+ok: true
+no: false
+servers:
+  - host: localhost
+    port: 8080
+ports:
+  80: http
+`
+)
+
+type (
+	Config struct {
+		Number int  `yaml:"number" toml:"number"`
+		OK     bool `yaml:"ok" toml:"ok"`
+	}
+)
+
+func TestYAML_Assert(t *testing.T) {
+	NewClient(t).
+		To(Mux).
+		Get("/config/yaml").
+		Test().
+		StatusOK().
+		YAML().
+		Exist("servers").
+		NotExist("clients").
+		Int("number", 1).
+		Uint("number", 1).
+		Float("number", 1).
+		Time("time", AssertDataTime).
+		True("ok").
+		False("no").
+		String("servers.0.host", "localhost").
+		Int("servers.0.port", 8080).
+		String("ports.80", "http").
+		NotEmpty()
+}
+
+func TestYAML_Empty(t *testing.T) {
+	NewYAML([]byte(""), t).
+		Empty().
+		JSON.Empty()
+}
+
+func TestYAML_Bind(t *testing.T) {
+	config := new(Config)
+	assert.Nil(t, NewYAML([]byte(YAMLAssertData), t).Bind(config))
+	assert.Equal(t, 1, config.Number)
+	assert.True(t, config.OK)
+}
+
+func ConfigYAMLHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(HeaderContentType, MIMEApplicationYAML)
+	io.WriteString(w, YAMLAssertData)
+}